@@ -4,32 +4,40 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/tapshow/tapshow/internal/compose"
 	"github.com/tapshow/tapshow/internal/config"
 	"github.com/tapshow/tapshow/internal/display"
+	"github.com/tapshow/tapshow/internal/i18n"
 	"github.com/tapshow/tapshow/internal/input"
 	"github.com/tapshow/tapshow/internal/privacy"
 	"github.com/tapshow/tapshow/internal/processor"
+	"github.com/tapshow/tapshow/internal/trainer"
 )
 
 var version = "dev"
 
 func main() {
+	setupLocale()
+
 	rootCmd := &cobra.Command{
 		Use:   "tapshow",
-		Short: "Keystroke visualizer for Wayland",
-		Long: `tapshow displays your keystrokes as a minimal overlay window.
-Designed for screen recordings, presentations, and live coding.`,
-		RunE: run,
+		Short: i18n.T("root_short"),
+		Long:  i18n.T("root_long"),
+		RunE:  run,
 	}
 
+	rootCmd.SetErrPrefix(i18n.T("error_prefix"))
 	rootCmd.AddCommand(
 		configCmd(),
 		debugCmd(),
+		trainCmd(),
 		versionCmd(),
 	)
 
@@ -38,61 +46,148 @@ Designed for screen recordings, presentations, and live coding.`,
 	}
 }
 
+// setupLocale picks the language of CLI and display strings before any
+// command is built, so help output is translated too.
+func setupLocale() {
+	locale := ""
+	if cfg, err := config.Load(); err == nil {
+		locale = cfg.Appearance.Locale
+	}
+	dir, _ := config.LocaleDir()
+	// Without a configured locale, an unsupported LANG quietly falls back
+	// to English.
+	if err := i18n.SetLocale(locale, dir); err != nil && locale != "" {
+		fmt.Println(i18n.T("locale_not_loaded", err))
+	}
+}
+
 func run(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("loading config: %w", err)
+		return fmt.Errorf("%s: %w", i18n.T("err_loading_config"), err)
 	}
 
 	compositor := display.Detect()
-	fmt.Printf("Detected compositor: %s\n", compositor)
+	fmt.Println(i18n.T("detected_compositor", compositor))
 
 	backend := display.New()
-	fmt.Println("Using GTK window backend")
+	fmt.Println(i18n.T("gtk_backend"))
 	showGTKWindowTips(compositor)
 
 	if err := backend.Init(cfg); err != nil {
-		return fmt.Errorf("initializing display: %w", err)
+		return fmt.Errorf("%s: %w", i18n.T("err_init_display"), err)
 	}
 
 	reader := input.NewReader()
 	if err := reader.Start(); err != nil {
-		return fmt.Errorf("starting input reader: %w", err)
+		return fmt.Errorf("%s: %w", i18n.T("err_input_reader"), err)
 	}
 	defer reader.Stop()
 
-	procCfg := processor.Config{
-		CombineModifiers: cfg.Behavior.CombineModifiers,
-		ShowModifierOnly: cfg.Behavior.ShowModifierOnly,
-		ShowHeldKeys:     cfg.Display.ShowHeldKeys,
-		HeldKeyTimeout:   cfg.HeldKeyTimeout(),
-		ResetTimeout:     cfg.Timeout(),
-		HistoryCount:     cfg.Display.HistoryCount,
-		ExcludedKeys:     cfg.Behavior.ExcludedKeys,
+	procCfg := processorConfig(cfg, reader.NumLock())
+	profiles := []profile{{name: i18n.T("profile_main"), cfg: procCfg}}
+	for _, p := range cfg.Profiles() {
+		profileCfg := processorConfig(p.Config, reader.NumLock())
+		// The window is built once from the main config, and the hotkeys
+		// stay so cycle_profile can always switch back.
+		profileCfg.Hotkeys = procCfg.Hotkeys
+		profileCfg.HistoryCount = procCfg.HistoryCount
+		profileCfg.ShowRate = procCfg.ShowRate
+		profileCfg.ShowModifierBar = procCfg.ShowModifierBar
+		profiles = append(profiles, profile{name: p.Name, cfg: profileCfg})
 	}
-	proc := processor.New(procCfg)
+	if slices.ContainsFunc(profiles, func(p profile) bool { return p.cfg.ComposeSequences }) {
+		table, err := loadComposeTable(cfg.Behavior.ComposeFile)
+		if err != nil {
+			fmt.Println(i18n.T("compose_not_loaded", err))
+		}
+		for i := range profiles {
+			profiles[i].cfg.ComposeTable = table
+		}
+	}
+	proc := processor.New(profiles[0].cfg)
+
+	// Subscribe before anything produces events, so none are missed.
+	displaySub := proc.Subscribe("display", 64, processor.DropOldest)
+	defer reportDropped(displaySub)
+
+	pause := &pauseState{apply: func(paused bool) {
+		backend.SetPaused(paused)
+		proc.SetPaused(paused)
+	}}
+
+	var hotkeyMu sync.Mutex
+	visible := true
+	current := 0
+	proc.OnHotkey(func(action string) {
+		hotkeyMu.Lock()
+		defer hotkeyMu.Unlock()
+		switch action {
+		case processor.ActionToggleOverlay:
+			visible = !visible
+			backend.SetVisible(visible)
+		case processor.ActionTogglePause:
+			if pause.toggleForced() {
+				fmt.Println(i18n.T("paused_hotkey"))
+			} else {
+				fmt.Println(i18n.T("pause_released"))
+			}
+		case processor.ActionCycleProfile:
+			current = (current + 1) % len(profiles)
+			proc.SetConfig(profiles[current].cfg)
+			proc.ShowHint(i18n.T("profile", profiles[current].name))
+			fmt.Println(i18n.T("profile", profiles[current].name))
+		}
+	})
 
 	go proc.Process(reader.Events())
 	defer proc.Stop()
 
 	privacyMonitor := privacy.NewMonitor(cfg.Privacy.PauseOnApps, func(paused bool) {
-		backend.SetPaused(paused)
+		pause.setApp(paused)
 		if paused {
-			fmt.Println("Privacy: paused (sensitive app focused)")
+			fmt.Println(i18n.T("paused_app"))
 		} else {
-			fmt.Println("Privacy: resumed")
+			fmt.Println(i18n.T("resumed"))
 		}
 	})
+	privacyMonitor.WatchMask(cfg.Privacy.MaskOnApps, func(masked bool) {
+		proc.SetMasked(masked)
+		if masked {
+			fmt.Println(i18n.T("masking"))
+		} else {
+			fmt.Println(i18n.T("unmasked"))
+		}
+	})
+	if cfg.Modal.Enabled {
+		privacyMonitor.OnFocus(func(info privacy.WindowInfo) {
+			proc.SetModalEditor(modalEditorFor(cfg.Modal, info))
+		})
+	}
+	if slices.ContainsFunc(profiles, func(p profile) bool {
+		return p.cfg.ResetOnFocusChange || p.cfg.Coaching || len(p.cfg.Rules) > 0
+	}) {
+		privacyMonitor.OnFocus(func(info privacy.WindowInfo) {
+			proc.FocusChanged(info.App())
+		})
+	}
 	privacyMonitor.Start()
 	defer privacyMonitor.Stop()
 
 	go func() {
-		for event := range proc.Events() {
-			if event.IsReset {
+		for event := range displaySub.Events() {
+			switch {
+			case event.IsReset:
 				backend.Reset()
-			} else {
+			case event.IsStatus():
+				// Indicators leave the history alone; rebuilding it would
+				// restart the fade of every entry.
 				backend.Show(event)
-				backend.UpdateHistory(proc.History())
+			case event.IsExpired || event.IsFading:
+				backend.UpdateHistory(event.History)
+			default:
+				backend.Show(event)
+				backend.UpdateHistory(event.History)
 			}
 		}
 	}()
@@ -102,33 +197,167 @@ func run(cmd *cobra.Command, args []string) error {
 
 	go func() {
 		<-sigChan
-		fmt.Println("\nShutting down...")
+		fmt.Println("\n" + i18n.T("shutting_down"))
 		backend.Stop()
 	}()
 
-	fmt.Println("tapshow running. Press Ctrl+C to exit.")
+	fmt.Println(i18n.T("running"))
 	return backend.Run()
 }
 
+// profile is a processor config to switch to with the cycle_profile hotkey.
+type profile struct {
+	name string
+	cfg  processor.Config
+}
+
+func processorConfig(cfg *config.Config, numLock bool) processor.Config {
+	return processor.Config{
+		CombineModifiers:   cfg.Behavior.CombineModifiers,
+		ShowModifierOnly:   cfg.Behavior.ShowModifierOnly,
+		ShowHeldKeys:       cfg.Display.ShowHeldKeys,
+		HeldKeyTimeout:     cfg.HeldKeyTimeout(),
+		ResetTimeout:       cfg.Timeout(),
+		ShortcutLifetime:   cfg.ShortcutLifetime(),
+		SpecialLifetime:    cfg.SpecialLifetime(),
+		PrintableLifetime:  cfg.PrintableLifetime(),
+		HistoryCount:       cfg.Display.HistoryCount,
+		ExcludedKeys:       cfg.Behavior.ExcludedKeys,
+		DetectChords:       cfg.Behavior.DetectChords,
+		ChordWindow:        cfg.ChordWindow(),
+		ExcludedClasses:    cfg.Behavior.ExcludedClasses,
+		ShortcutsOnly:      cfg.Behavior.ShortcutsOnly,
+		MaskPrintable:      cfg.Privacy.MaskPrintable,
+		ModalInsertMode:    cfg.Modal.InsertMode,
+		StickyModifiers:    cfg.Behavior.StickyModifiers,
+		StickyTimeout:      cfg.StickyTimeout(),
+		ComposeSequences:   cfg.Behavior.ComposeSequences,
+		DeadKeys:           cfg.Behavior.DeadKeys,
+		NumLock:            numLock,
+		KeypadAsDigits:     cfg.Behavior.KeypadAsDigits,
+		ResetOnFocusChange: cfg.Behavior.ResetOnFocusChange,
+		FocusDivider:       cfg.Display.FocusDivider,
+		ShowHoldDuration:   cfg.Display.ShowHoldDuration,
+		ScrollbackCount:    cfg.Recall.Scrollback,
+		RecallHotkey:       cfg.Recall.Hotkey,
+		RecallCount:        cfg.Recall.Count,
+		RecallDuration:     cfg.RecallDuration(),
+		ShowRate:           cfg.Display.ShowRate,
+		RateWindow:         cfg.RateWindow(),
+		ShowModifierBar:    cfg.Display.ShowModifierBar,
+		Coaching:           cfg.Coaching.Enabled,
+		CoachRules:         coachRules(cfg.Coaching.Rules),
+		CoachCooldown:      cfg.CoachCooldown(),
+		HintDuration:       cfg.HintDuration(),
+		RetractSecrets:     cfg.Privacy.Secrets.Enabled,
+		SecretRules: processor.SecretRules{
+			MinLength:      cfg.Privacy.Secrets.MinLength,
+			MinEntropyBits: cfg.Privacy.Secrets.MinEntropyBits,
+			MinClasses:     cfg.Privacy.Secrets.MinClasses,
+		},
+		Rules:         rules(cfg.Rules),
+		Glyphs:        cfg.Appearance.Glyphs,
+		Labels:        cfg.Labels,
+		KeyNames:      i18n.KeyNames(),
+		Notation:      cfg.Appearance.Notation,
+		ModifierOrder: cfg.Appearance.ModifierOrder,
+		Separator:     cfg.Appearance.Separator,
+		Hotkeys: map[string]string{
+			cfg.Hotkeys.ToggleOverlay: processor.ActionToggleOverlay,
+			cfg.Hotkeys.TogglePause:   processor.ActionTogglePause,
+			cfg.Hotkeys.ClearHistory:  processor.ActionClearHistory,
+			cfg.Hotkeys.CycleProfile:  processor.ActionCycleProfile,
+		},
+	}
+}
+
+// pauseState combines the privacy monitor's pause with one forced by hotkey.
+type pauseState struct {
+	mu     sync.Mutex
+	app    bool
+	forced bool
+	apply  func(paused bool)
+}
+
+func (s *pauseState) setApp(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.app = paused
+	s.apply(s.app || s.forced)
+}
+
+func (s *pauseState) toggleForced() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forced = !s.forced
+	s.apply(s.app || s.forced)
+	return s.forced
+}
+
+func coachRules(rules []config.CoachRule) []processor.CoachRule {
+	result := make([]processor.CoachRule, len(rules))
+	for i, r := range rules {
+		result[i] = processor.CoachRule{Keys: r.Keys, Repeat: r.Repeat, Apps: r.Apps, Hint: r.Hint}
+	}
+	return result
+}
+
+func rules(rules []config.Rule) []processor.Rule {
+	result := make([]processor.Rule, len(rules))
+	for i, r := range rules {
+		result[i] = processor.Rule{
+			Combo:    r.Combo,
+			Class:    r.Class,
+			Apps:     r.Apps,
+			Repeat:   r.Repeat,
+			Action:   r.Action,
+			Label:    r.Label,
+			Lifetime: time.Duration(r.LifetimeMs) * time.Millisecond,
+		}
+	}
+	return result
+}
+
+func loadComposeTable(path string) (*compose.Table, error) {
+	if path != "" {
+		return compose.Load(path)
+	}
+	return compose.LoadDefault()
+}
+
+func modalEditorFor(cfg config.ModalConfig, info privacy.WindowInfo) processor.ModalEditor {
+	for _, app := range cfg.VimApps {
+		if info.MatchesAny(app) {
+			return processor.EditorVim
+		}
+	}
+	for _, app := range cfg.HelixApps {
+		if info.MatchesAny(app) {
+			return processor.EditorHelix
+		}
+	}
+	return processor.EditorNone
+}
+
 func showGTKWindowTips(compositor display.Compositor) {
 	switch compositor {
 	case display.CompositorKDE:
-		fmt.Println("Tip: Right-click the window → More Actions → Keep Above Others")
+		fmt.Println(i18n.T("tip_kde"))
 	case display.CompositorGNOME:
-		fmt.Println("Tip: Right-click the window → Always on Top")
+		fmt.Println(i18n.T("tip_gnome"))
 	}
 }
 
 func configCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
-		Short: "Configuration management",
+		Short: i18n.T("config_short"),
 	}
 
 	cmd.AddCommand(
 		&cobra.Command{
 			Use:   "path",
-			Short: "Print the configuration file path",
+			Short: i18n.T("config_path_short"),
 			RunE: func(cmd *cobra.Command, args []string) error {
 				path, err := config.Path()
 				if err != nil {
@@ -140,7 +369,7 @@ func configCmd() *cobra.Command {
 		},
 		&cobra.Command{
 			Use:   "init",
-			Short: "Create a default configuration file",
+			Short: i18n.T("config_init_short"),
 			RunE: func(cmd *cobra.Command, args []string) error {
 				path, err := config.Path()
 				if err != nil {
@@ -148,7 +377,7 @@ func configCmd() *cobra.Command {
 				}
 
 				if _, err := os.Stat(path); err == nil {
-					return fmt.Errorf("config already exists at %s", path)
+					return fmt.Errorf("%s: %s", i18n.T("err_config_exists"), path)
 				}
 
 				cfg := config.Default()
@@ -156,7 +385,7 @@ func configCmd() *cobra.Command {
 					return err
 				}
 
-				fmt.Printf("Created config at: %s\n", path)
+				fmt.Println(i18n.T("config_created", path))
 				return nil
 			},
 		},
@@ -168,18 +397,18 @@ func configCmd() *cobra.Command {
 func debugCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "debug",
-		Short: "Debugging utilities",
+		Short: i18n.T("debug_short"),
 	}
 
 	cmd.AddCommand(
 		&cobra.Command{
 			Use:   "active-app",
-			Short: "Continuously print the focused window info as it changes",
-			Long:  "Continuously print the focused window info as it changes. Useful for finding apps to add to privacy.pause_on_apps config",
+			Short: i18n.T("active_app_short"),
+			Long:  i18n.T("active_app_long"),
 			Run: func(cmd *cobra.Command, args []string) {
 				compositor := display.Detect()
-				fmt.Printf("Detected compositor: %s\n", compositor)
-				fmt.Println("Watching for focus changes... (Ctrl+C to exit)")
+				fmt.Println(i18n.T("detected_compositor", compositor))
+				fmt.Println(i18n.T("watching_focus"))
 				fmt.Println()
 
 				sigChan := make(chan os.Signal, 1)
@@ -192,7 +421,7 @@ func debugCmd() *cobra.Command {
 				for {
 					select {
 					case <-sigChan:
-						fmt.Println("\nStopped.")
+						fmt.Println("\n" + i18n.T("stopped"))
 						return
 					case <-ticker.C:
 						info := privacy.GetFocusedWindow(compositor)
@@ -200,7 +429,7 @@ func debugCmd() *cobra.Command {
 						if infoStr != lastInfo {
 							lastInfo = infoStr
 							if info.IsEmpty() {
-								fmt.Println("(no focused window)")
+								fmt.Println(i18n.T("no_focused_window"))
 							} else {
 								fmt.Println(infoStr)
 							}
@@ -214,10 +443,130 @@ func debugCmd() *cobra.Command {
 	return cmd
 }
 
+// trainFeedbackDelay is how long the result of an answer is shown before the
+// next card.
+const trainFeedbackDelay = 1500 * time.Millisecond
+
+func trainCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "train <deck.toml>",
+		Short: i18n.T("train_short"),
+		Long:  i18n.T("train_long"),
+		Args:  cobra.ExactArgs(1),
+		RunE:  runTrain,
+	}
+}
+
+func runTrain(cmd *cobra.Command, args []string) error {
+	deck, err := trainer.LoadDeck(args[0])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("%s: %w", i18n.T("err_loading_config"), err)
+	}
+
+	backend := display.New()
+	if err := backend.Init(cfg); err != nil {
+		return fmt.Errorf("%s: %w", i18n.T("err_init_display"), err)
+	}
+
+	reader := input.NewReader()
+	if err := reader.Start(); err != nil {
+		return fmt.Errorf("%s: %w", i18n.T("err_input_reader"), err)
+	}
+	defer reader.Stop()
+
+	procCfg := processor.DefaultConfig()
+	procCfg.ShowHeldKeys = false
+	procCfg.HistoryCount = cfg.Display.HistoryCount
+	procCfg.NumLock = reader.NumLock()
+	proc := processor.New(procCfg)
+	trainerSub := proc.Subscribe("trainer", 64, processor.DropOldest)
+
+	go proc.Process(reader.Events())
+	defer proc.Stop()
+
+	session := trainer.NewSession(deck)
+	go func() {
+		promptedAt := promptCard(backend, session)
+		for event := range trainerSub.Events() {
+			if event.IsReset {
+				backend.Reset()
+				continue
+			}
+			// Only new entries answer a card, not updates or indicators,
+			// and not keys pressed while the previous result was shown.
+			if event.ID == 0 || event.Combo == "" || event.IsFading || event.Timestamp.Before(promptedAt) || session.Done() {
+				continue
+			}
+			backend.Show(event)
+			backend.UpdateHistory(event.History)
+
+			result := session.Answer(event.Combo)
+			if result.Correct {
+				backend.ShowPrompt(i18n.T("train_correct", result.Card.Combo))
+			} else {
+				backend.ShowPrompt(i18n.T("train_wrong", result.Answer, result.Card.Combo))
+			}
+			time.Sleep(trainFeedbackDelay)
+
+			if session.Done() {
+				showScore(backend, session)
+				continue
+			}
+			promptedAt = promptCard(backend, session)
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		<-sigChan
+		fmt.Println()
+		backend.Stop()
+	}()
+
+	if deck.Name != "" {
+		fmt.Println(i18n.T("training", deck.Name))
+	}
+	fmt.Println(i18n.T("train_help"))
+	return backend.Run()
+}
+
+func reportDropped(sub *processor.Subscription) {
+	if n := sub.Dropped(); n > 0 {
+		fmt.Println(i18n.T("dropped", sub.Name(), n))
+	}
+}
+
+func promptCard(backend display.Backend, session *trainer.Session) time.Time {
+	card, _ := session.Current()
+	n, total := session.Position()
+	backend.ShowPrompt(i18n.T("train_card", n, total, card.Description))
+	return time.Now()
+}
+
+func showScore(backend display.Backend, session *trainer.Session) {
+	correct, total := session.Score()
+	summary := i18n.T("score", correct, total)
+	backend.ShowPrompt(summary)
+
+	fmt.Println(summary)
+	for _, r := range session.Results() {
+		if !r.Correct {
+			fmt.Println(i18n.T("score_miss", r.Card.Description, r.Answer, r.Card.Combo))
+		}
+	}
+}
+
 func versionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
-		Short: "Print version information",
+		Short: i18n.T("version_short"),
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Printf("tapshow %s\n", version)
 		},
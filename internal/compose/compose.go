@@ -0,0 +1,289 @@
+package compose
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	systemLocaleDir = "/usr/share/X11/locale"
+	maxIncludeDepth = 5
+)
+
+// Table maps key sequences, written as X11 keysym names such as
+// "Multi_key apostrophe e", to the text they produce.
+type Table struct {
+	results  map[string]string
+	prefixes map[string]bool
+}
+
+func NewTable() *Table {
+	return &Table{
+		results:  make(map[string]string),
+		prefixes: make(map[string]bool),
+	}
+}
+
+func (t *Table) Add(seq []string, result string) {
+	t.results[strings.Join(seq, " ")] = result
+	for i := 1; i < len(seq); i++ {
+		t.prefixes[strings.Join(seq[:i], " ")] = true
+	}
+}
+
+// Lookup returns the result of a complete sequence, and whether seq is the
+// start of a longer sequence.
+func (t *Table) Lookup(seq []string) (result string, ok, isPrefix bool) {
+	key := strings.Join(seq, " ")
+	result, ok = t.results[key]
+	return result, ok, t.prefixes[key]
+}
+
+func (t *Table) Len() int {
+	return len(t.results)
+}
+
+// LoadDefault loads the Compose table the way X11 and xkbcommon look it up:
+// $XCOMPOSEFILE, then ~/.XCompose, then the table for the current locale.
+func LoadDefault() (*Table, error) {
+	if path := os.Getenv("XCOMPOSEFILE"); path != "" {
+		return Load(path)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		path := filepath.Join(home, ".XCompose")
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		}
+	}
+
+	path, err := localeFile(locale())
+	if err != nil {
+		return nil, err
+	}
+	return Load(path)
+}
+
+func Load(path string) (*Table, error) {
+	t := NewTable()
+	if err := t.loadFile(path, 0); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Table) loadFile(path string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("compose include depth exceeded at %s", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening compose file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "include"); ok {
+			include, err := expandInclude(strings.TrimSpace(rest))
+			if err != nil {
+				return err
+			}
+			if err := t.loadFile(include, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if seq, result, ok := parseLine(line); ok {
+			t.Add(seq, result)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseLine parses a line like `<Multi_key> <apostrophe> <e> : "é" eacute`.
+func parseLine(line string) (seq []string, result string, ok bool) {
+	lhs, rhs, found := strings.Cut(line, ":")
+	if !found {
+		return nil, "", false
+	}
+
+	for _, field := range strings.Fields(lhs) {
+		if !strings.HasPrefix(field, "<") || !strings.HasSuffix(field, ">") {
+			return nil, "", false
+		}
+		seq = append(seq, field[1:len(field)-1])
+	}
+	if len(seq) == 0 {
+		return nil, "", false
+	}
+
+	rhs = strings.TrimSpace(rhs)
+	if !strings.HasPrefix(rhs, `"`) {
+		return nil, "", false
+	}
+	end := closingQuote(rhs)
+	if end < 0 {
+		return nil, "", false
+	}
+	result, err := strconv.Unquote(rhs[:end+1])
+	if err != nil {
+		return nil, "", false
+	}
+
+	return seq, result, true
+}
+
+// closingQuote returns the index of the quote ending the string that starts
+// at s[0], honouring backslash escapes.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func expandInclude(arg string) (string, error) {
+	path, err := strconv.Unquote(arg)
+	if err != nil {
+		return "", fmt.Errorf("invalid compose include %s", arg)
+	}
+
+	if strings.Contains(path, "%H") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = strings.ReplaceAll(path, "%H", home)
+	}
+	if strings.Contains(path, "%L") {
+		file, err := localeFile(locale())
+		if err != nil {
+			return "", err
+		}
+		path = strings.ReplaceAll(path, "%L", file)
+	}
+	return strings.ReplaceAll(path, "%S", systemLocaleDir), nil
+}
+
+func locale() string {
+	for _, env := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			return value
+		}
+	}
+	return "C"
+}
+
+// localeFile finds the system Compose file for a locale via compose.dir.
+func localeFile(locale string) (string, error) {
+	name, codeset, _ := strings.Cut(locale, ".")
+	codeset, _, _ = strings.Cut(codeset, "@")
+	if strings.EqualFold(codeset, "utf8") || strings.EqualFold(codeset, "utf-8") || codeset == "" {
+		codeset = "UTF-8"
+	}
+	if name == "C" || name == "POSIX" {
+		name = "en_US"
+	}
+	full := name + "." + codeset
+
+	dirFile := filepath.Join(systemLocaleDir, "compose.dir")
+	f, err := os.Open(dirFile)
+	if err != nil {
+		return "", fmt.Errorf("opening %s: %w", dirFile, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[1] == full {
+			return filepath.Join(systemLocaleDir, strings.TrimSuffix(fields[0], ":")), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("no compose table for locale %s", full)
+}
+
+var keysymNames = map[rune]string{
+	' ':  "space",
+	'!':  "exclam",
+	'"':  "quotedbl",
+	'#':  "numbersign",
+	'$':  "dollar",
+	'%':  "percent",
+	'&':  "ampersand",
+	'\'': "apostrophe",
+	'(':  "parenleft",
+	')':  "parenright",
+	'*':  "asterisk",
+	'+':  "plus",
+	',':  "comma",
+	'-':  "minus",
+	'.':  "period",
+	'/':  "slash",
+	':':  "colon",
+	';':  "semicolon",
+	'<':  "less",
+	'=':  "equal",
+	'>':  "greater",
+	'?':  "question",
+	'@':  "at",
+	'[':  "bracketleft",
+	'\\': "backslash",
+	']':  "bracketright",
+	'^':  "asciicircum",
+	'_':  "underscore",
+	'`':  "grave",
+	'{':  "braceleft",
+	'|':  "bar",
+	'}':  "braceright",
+	'~':  "asciitilde",
+}
+
+// KeysymName returns the X11 keysym name of an ASCII character.
+func KeysymName(r rune) string {
+	if name, ok := keysymNames[r]; ok {
+		return name
+	}
+	return string(r)
+}
+
+var deadKeysyms = map[rune]string{
+	'\'': "dead_acute",
+	'`':  "dead_grave",
+	'^':  "dead_circumflex",
+	'~':  "dead_tilde",
+	'"':  "dead_diaeresis",
+	',':  "dead_cedilla",
+}
+
+// DeadKeysym returns the dead keysym a character acts as on layouts with
+// dead keys, e.g. "dead_acute" for an apostrophe.
+func DeadKeysym(r rune) (string, bool) {
+	name, ok := deadKeysyms[r]
+	return name, ok
+}
@@ -0,0 +1,77 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		seq    []string
+		result string
+		ok     bool
+	}{
+		{`<Multi_key> <apostrophe> <e>		: "é"	eacute # LATIN SMALL LETTER E WITH ACUTE`, []string{"Multi_key", "apostrophe", "e"}, "é", true},
+		{`<dead_acute> <e>	: "é"`, []string{"dead_acute", "e"}, "é", true},
+		{`<Multi_key> <slash> <slash>		: "\\"	backslash`, []string{"Multi_key", "slash", "slash"}, `\`, true},
+		{`<Multi_key> <colon> <parenright> : "☺" # with a "quote" in the comment`, []string{"Multi_key", "colon", "parenright"}, "☺", true},
+		{`<Multi_key> <e> : eacute`, nil, "", false},
+		{`garbage`, nil, "", false},
+	}
+
+	for _, tt := range tests {
+		seq, result, ok := parseLine(tt.line)
+		if ok != tt.ok || result != tt.result || len(seq) != len(tt.seq) {
+			t.Errorf("parseLine(%q) = %q, %q, %v; want %q, %q, %v", tt.line, seq, result, ok, tt.seq, tt.result, tt.ok)
+			continue
+		}
+		for i := range seq {
+			if seq[i] != tt.seq[i] {
+				t.Errorf("parseLine(%q) seq[%d] = %q, want %q", tt.line, i, seq[i], tt.seq[i])
+			}
+		}
+	}
+}
+
+func TestLoadWithInclude(t *testing.T) {
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "base")
+	user := filepath.Join(tmpDir, "user")
+
+	if err := os.WriteFile(base, []byte(`<Multi_key> <apostrophe> <e> : "é"`+"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	content := "# user table\ninclude \"" + base + "\"\n<Multi_key> <o> <c> : \"©\"\n"
+	if err := os.WriteFile(user, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	table, err := Load(user)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if table.Len() != 2 {
+		t.Errorf("Table length = %d, want 2", table.Len())
+	}
+
+	if result, ok, _ := table.Lookup([]string{"Multi_key", "apostrophe", "e"}); !ok || result != "é" {
+		t.Errorf("Lookup from included file = %q, %v; want é, true", result, ok)
+	}
+	if _, ok, isPrefix := table.Lookup([]string{"Multi_key", "o"}); ok || !isPrefix {
+		t.Errorf("Lookup of prefix = %v, %v; want false, true", ok, isPrefix)
+	}
+	if _, ok, isPrefix := table.Lookup([]string{"Multi_key", "x"}); ok || isPrefix {
+		t.Errorf("Lookup of unknown sequence = %v, %v; want false, false", ok, isPrefix)
+	}
+}
+
+func TestKeysymName(t *testing.T) {
+	if got := KeysymName('\''); got != "apostrophe" {
+		t.Errorf("KeysymName('\\'') = %q, want apostrophe", got)
+	}
+	if got := KeysymName('e'); got != "e" {
+		t.Errorf("KeysymName('e') = %q, want e", got)
+	}
+}
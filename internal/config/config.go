@@ -1,19 +1,40 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/tapshow/tapshow/internal/i18n"
+	"github.com/tapshow/tapshow/internal/input"
 )
 
 type Config struct {
-	Display    DisplayConfig    `toml:"display"`
-	Appearance AppearanceConfig `toml:"appearance"`
-	Behavior   BehaviorConfig   `toml:"behavior"`
-	Privacy    PrivacyConfig    `toml:"privacy"`
+	Display    DisplayConfig     `toml:"display"`
+	Appearance AppearanceConfig  `toml:"appearance"`
+	Behavior   BehaviorConfig    `toml:"behavior"`
+	Privacy    PrivacyConfig     `toml:"privacy"`
+	Modal      ModalConfig       `toml:"modal"`
+	Recall     RecallConfig      `toml:"recall"`
+	Hotkeys    HotkeysConfig     `toml:"hotkeys"`
+	Coaching   CoachingConfig    `toml:"coaching"`
+	Labels     map[string]string `toml:"labels"`
+	Rules      []Rule            `toml:"rules"`
+
+	profiles []Profile
+}
+
+// Profile is a named set of settings layered over the main config, e.g. for
+// screencasts, switched with the cycle_profile hotkey.
+type Profile struct {
+	Name   string
+	Config *Config
 }
 
 type DisplayConfig struct {
@@ -24,6 +45,18 @@ type DisplayConfig struct {
 	HeldKeyTimeoutMs int    `toml:"held_key_timeout_ms"`
 	HistoryCount     int    `toml:"history_count"`
 	ShowHeldKeys     bool   `toml:"show_held_keys"`
+	FocusDivider     string `toml:"focus_divider"` // none, line, label
+	ShowHoldDuration bool   `toml:"show_hold_duration"`
+
+	// Per entry type lifetimes; zero uses timeout_ms.
+	ShortcutLifetimeMs  int `toml:"shortcut_lifetime_ms"`
+	SpecialLifetimeMs   int `toml:"special_lifetime_ms"`
+	PrintableLifetimeMs int `toml:"printable_lifetime_ms"`
+
+	ShowRate     bool `toml:"show_rate"`
+	RateWindowMs int  `toml:"rate_window_ms"`
+
+	ShowModifierBar bool `toml:"show_modifier_bar"`
 }
 
 type AppearanceConfig struct {
@@ -31,16 +64,89 @@ type AppearanceConfig struct {
 	FontSize     int     `toml:"font_size"`
 	Opacity      float64 `toml:"opacity"`
 	CornerRadius int     `toml:"corner_radius"`
+	Glyphs       string  `toml:"glyphs"` // text, symbols, mac-style
+
+	Notation      string   `toml:"notation"` // default, emacs, vim, mac, keycaps
+	ModifierOrder []string `toml:"modifier_order"`
+	Separator     string   `toml:"separator"`
+	Locale        string   `toml:"locale"` // e.g. de_DE; empty uses LANG
 }
 
 type BehaviorConfig struct {
-	CombineModifiers bool     `toml:"combine_modifiers"`
-	ShowModifierOnly bool     `toml:"show_modifier_only"`
-	ExcludedKeys     []string `toml:"excluded_keys"`
+	CombineModifiers   bool     `toml:"combine_modifiers"`
+	ShowModifierOnly   bool     `toml:"show_modifier_only"`
+	ExcludedKeys       []string `toml:"excluded_keys"`
+	DetectChords       bool     `toml:"detect_chords"`
+	ChordWindowMs      int      `toml:"chord_window_ms"`
+	ExcludedClasses    []string `toml:"excluded_classes"` // letters, digits, punctuation, navigation, function, keypad, unmodified_printable
+	ShortcutsOnly      bool     `toml:"shortcuts_only"`
+	StickyModifiers    bool     `toml:"sticky_modifiers"`
+	StickyTimeoutMs    int      `toml:"sticky_timeout_ms"`
+	ComposeSequences   bool     `toml:"compose_sequences"`
+	DeadKeys           []string `toml:"dead_keys"`
+	ComposeFile        string   `toml:"compose_file"`
+	KeypadAsDigits     bool     `toml:"keypad_as_digits"`
+	ResetOnFocusChange bool     `toml:"reset_on_focus_change"`
 }
 
 type PrivacyConfig struct {
-	PauseOnApps AppMatchers `toml:"pause_on_apps"`
+	PauseOnApps   AppMatchers   `toml:"pause_on_apps"`
+	MaskPrintable bool          `toml:"mask_printable"`
+	MaskOnApps    AppMatchers   `toml:"mask_on_apps"`
+	Secrets       SecretsConfig `toml:"secrets"`
+}
+
+type SecretsConfig struct {
+	Enabled        bool    `toml:"enabled"`
+	MinLength      int     `toml:"min_length"`
+	MinEntropyBits float64 `toml:"min_entropy_bits"`
+	MinClasses     int     `toml:"min_classes"`
+}
+
+type ModalConfig struct {
+	Enabled    bool     `toml:"enabled"`
+	VimApps    []string `toml:"vim_apps"`
+	HelixApps  []string `toml:"helix_apps"`
+	InsertMode string   `toml:"insert_mode"` // show, hide, aggregate
+}
+
+type RecallConfig struct {
+	Hotkey     string `toml:"hotkey"`
+	Scrollback int    `toml:"scrollback"`
+	Count      int    `toml:"count"`
+	DurationMs int    `toml:"duration_ms"`
+}
+
+type HotkeysConfig struct {
+	ToggleOverlay string `toml:"toggle_overlay"`
+	TogglePause   string `toml:"toggle_pause"`
+	ClearHistory  string `toml:"clear_history"`
+	CycleProfile  string `toml:"cycle_profile"`
+}
+
+type CoachingConfig struct {
+	Enabled        bool        `toml:"enabled"`
+	CooldownMs     int         `toml:"cooldown_ms"`
+	HintDurationMs int         `toml:"hint_duration_ms"`
+	Rules          []CoachRule `toml:"rules"`
+}
+
+type CoachRule struct {
+	Keys   []string `toml:"keys"`
+	Repeat int      `toml:"repeat"`
+	Apps   []string `toml:"apps"`
+	Hint   string   `toml:"hint"`
+}
+
+// Rule transforms matching keys; see configs/default.toml.
+type Rule struct {
+	Combo      string   `toml:"combo"`
+	Class      string   `toml:"class"`
+	Apps       []string `toml:"apps"`
+	Repeat     int      `toml:"repeat"`
+	Action     string   `toml:"action"` // hide, relabel, annotate, mask, extend
+	Label      string   `toml:"label"`
+	LifetimeMs int      `toml:"lifetime_ms"`
 }
 
 type AppMatchers []AppMatcher
@@ -63,7 +169,7 @@ func (a *AppMatchers) UnmarshalTOML(data any) error {
 			items = append(items, m)
 		}
 	default:
-		return fmt.Errorf("expected array, got %T", data)
+		return fmt.Errorf("%s: %T", i18n.T("err_app_matchers"), data)
 	}
 
 	*a = make([]AppMatcher, 0, len(items))
@@ -86,7 +192,7 @@ func (a *AppMatchers) UnmarshalTOML(data any) error {
 				m.Title = title
 			}
 		default:
-			return fmt.Errorf("unexpected type %T in pause_on_apps", item)
+			return fmt.Errorf("%s: %T", i18n.T("err_app_matcher"), item)
 		}
 		*a = append(*a, m)
 	}
@@ -103,20 +209,84 @@ func Default() *Config {
 			HeldKeyTimeoutMs: 500,
 			HistoryCount:     4,
 			ShowHeldKeys:     true,
+			FocusDivider:     "label",
+			ShowHoldDuration: false,
+
+			ShortcutLifetimeMs:  0,
+			SpecialLifetimeMs:   0,
+			PrintableLifetimeMs: 0,
+
+			ShowRate:     false,
+			RateWindowMs: 10000,
+
+			ShowModifierBar: false,
 		},
 		Appearance: AppearanceConfig{
 			Theme:        "dark",
 			FontSize:     18,
 			Opacity:      0.85,
 			CornerRadius: 8,
+			Glyphs:       "text",
+			Notation:     "default",
 		},
 		Behavior: BehaviorConfig{
-			CombineModifiers: true,
-			ShowModifierOnly: false,
-			ExcludedKeys:     []string{},
+			CombineModifiers:   true,
+			ShowModifierOnly:   false,
+			ExcludedKeys:       []string{},
+			DetectChords:       false,
+			ChordWindowMs:      30,
+			ExcludedClasses:    []string{},
+			ShortcutsOnly:      false,
+			StickyModifiers:    false,
+			StickyTimeoutMs:    1000,
+			ComposeSequences:   false,
+			DeadKeys:           []string{},
+			ComposeFile:        "",
+			KeypadAsDigits:     false,
+			ResetOnFocusChange: false,
 		},
 		Privacy: PrivacyConfig{
-			PauseOnApps: AppMatchers{},
+			PauseOnApps:   AppMatchers{},
+			MaskPrintable: false,
+			MaskOnApps:    AppMatchers{},
+			Secrets: SecretsConfig{
+				Enabled:        false,
+				MinLength:      8,
+				MinEntropyBits: 24,
+				MinClasses:     3,
+			},
+		},
+		Modal: ModalConfig{
+			Enabled:    false,
+			VimApps:    []string{"vim"},
+			HelixApps:  []string{"helix"},
+			InsertMode: "aggregate",
+		},
+		Recall: RecallConfig{
+			Hotkey:     "",
+			Scrollback: 50,
+			Count:      10,
+			DurationMs: 5000,
+		},
+		Hotkeys: HotkeysConfig{
+			ToggleOverlay: "",
+			TogglePause:   "",
+			ClearHistory:  "",
+			CycleProfile:  "",
+		},
+		Coaching: CoachingConfig{
+			Enabled:        false,
+			CooldownMs:     60000,
+			HintDurationMs: 4000,
+			Rules: []CoachRule{
+				{Keys: []string{"Left"}, Repeat: 15, Hint: "Ctrl+Left jumps by word"},
+				{Keys: []string{"Right"}, Repeat: 15, Hint: "Ctrl+Right jumps by word"},
+				{Keys: []string{"Backspace"}, Repeat: 10, Hint: "Ctrl+Backspace deletes a whole word"},
+				{Keys: []string{"Delete"}, Repeat: 10, Hint: "Ctrl+Delete deletes a whole word"},
+				{Keys: []string{"Down"}, Repeat: 20, Hint: "PageDown moves by a whole page"},
+				{Keys: []string{"Up"}, Repeat: 20, Hint: "PageUp moves by a whole page"},
+				{Keys: []string{"Home", "Shift+End"}, Hint: "Shift+Down from the line start also selects the line"},
+			},
 		},
 	}
 }
@@ -138,16 +308,97 @@ func LoadFrom(path string) (*Config, error) {
 		if os.IsNotExist(err) {
 			return cfg, nil
 		}
-		return nil, fmt.Errorf("reading config: %w", err)
+		return nil, fmt.Errorf("%s: %w", i18n.T("err_reading_config"), err)
 	}
 
 	if _, err := toml.Decode(string(data), cfg); err != nil {
-		return nil, fmt.Errorf("parsing config: %w", err)
+		return nil, fmt.Errorf("%s: %w", i18n.T("err_parsing_config"), err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T("err_invalid_config"), err)
+	}
+	if cfg.profiles, err = loadProfiles(string(data)); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// loadProfiles decodes each [profiles.<name>] table over a fresh copy of the
+// main config, in the order they appear in the file.
+func loadProfiles(data string) ([]Profile, error) {
+	var raw struct {
+		Profiles map[string]toml.Primitive `toml:"profiles"`
+	}
+	md, err := toml.Decode(data, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T("err_parsing_config"), err)
+	}
+
+	// Keys lists profiles defined only through subtables like
+	// [profiles.<name>.display] by their subtables.
+	var names []string
+	for _, key := range md.Keys() {
+		if len(key) >= 2 && key[0] == "profiles" && !slices.Contains(names, key[1]) {
+			names = append(names, key[1])
+		}
+	}
+
+	var profiles []Profile
+	for _, name := range names {
+		cfg := Default()
+		if _, err := toml.Decode(data, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", i18n.T("err_parsing_config"), err)
+		}
+		if err := md.PrimitiveDecode(raw.Profiles[name], cfg); err != nil {
+			return nil, fmt.Errorf("%s %q: %w", i18n.T("err_parsing_profile"), name, err)
+		}
+		if err := cfg.validate(); err != nil {
+			return nil, fmt.Errorf("%s %q: %w", i18n.T("err_invalid_profile"), name, err)
+		}
+		profiles = append(profiles, Profile{Name: name, Config: cfg})
+	}
+	return profiles, nil
+}
+
+// Profiles returns the profiles defined in the config file, in file order.
+func (c *Config) Profiles() []Profile {
+	return c.profiles
+}
+
+// validate rejects settings that would otherwise be silently ignored.
+func (c *Config) validate() error {
+	if c.Display.RateWindowMs <= 0 {
+		return fmt.Errorf("%s: %d", i18n.T("err_rate_window"), c.Display.RateWindowMs)
+	}
+	for i, rule := range c.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("%s %d: %w", i18n.T("err_rule"), i+1, err)
+		}
+	}
+	return nil
+}
+
+var ruleActions = []string{"hide", "relabel", "annotate", "mask", "extend"}
+
+func (r Rule) validate() error {
+	if !slices.Contains(ruleActions, r.Action) {
+		return fmt.Errorf("%s %q (%s)", i18n.T("err_rule_action"), r.Action, strings.Join(ruleActions, ", "))
+	}
+	if r.Class != "" && !strings.EqualFold(strings.TrimSpace(r.Class), "unmodified_printable") {
+		if _, ok := input.ParseKeyClass(r.Class); !ok {
+			return fmt.Errorf("%s %q", i18n.T("err_rule_class"), r.Class)
+		}
+	}
+	if (r.Action == "relabel" || r.Action == "annotate") && r.Label == "" {
+		return fmt.Errorf("%s: %q", i18n.T("err_rule_label"), r.Action)
+	}
+	if r.Action == "extend" && r.LifetimeMs <= 0 {
+		return errors.New(i18n.T("err_rule_lifetime"))
+	}
+	return nil
+}
+
 func (c *Config) Save() error {
 	path, err := Path()
 	if err != nil {
@@ -160,18 +411,18 @@ func (c *Config) Save() error {
 func (c *Config) SaveTo(path string) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return fmt.Errorf("creating config dir: %w", err)
+		return fmt.Errorf("%s: %w", i18n.T("err_config_dir"), err)
 	}
 
 	f, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("creating config file: %w", err)
+		return fmt.Errorf("%s: %w", i18n.T("err_config_file"), err)
 	}
 	defer f.Close()
 
 	encoder := toml.NewEncoder(f)
 	if err := encoder.Encode(c); err != nil {
-		return fmt.Errorf("encoding config: %w", err)
+		return fmt.Errorf("%s: %w", i18n.T("err_encoding_config"), err)
 	}
 
 	return nil
@@ -180,16 +431,61 @@ func (c *Config) SaveTo(path string) error {
 func Path() (string, error) {
 	configDir, err := os.UserConfigDir()
 	if err != nil {
-		return "", fmt.Errorf("getting config dir: %w", err)
+		return "", fmt.Errorf("%s: %w", i18n.T("err_user_config_dir"), err)
 	}
 
 	return filepath.Join(configDir, "tapshow", "config.toml"), nil
 }
 
+// LocaleDir is where user translations that extend the built-in ones live.
+func LocaleDir() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "locales"), nil
+}
+
 func (c *Config) Timeout() time.Duration {
 	return time.Duration(c.Display.TimeoutMs) * time.Millisecond
 }
 
+func (c *Config) ShortcutLifetime() time.Duration {
+	return time.Duration(c.Display.ShortcutLifetimeMs) * time.Millisecond
+}
+
+func (c *Config) SpecialLifetime() time.Duration {
+	return time.Duration(c.Display.SpecialLifetimeMs) * time.Millisecond
+}
+
+func (c *Config) PrintableLifetime() time.Duration {
+	return time.Duration(c.Display.PrintableLifetimeMs) * time.Millisecond
+}
+
+func (c *Config) RecallDuration() time.Duration {
+	return time.Duration(c.Recall.DurationMs) * time.Millisecond
+}
+
+func (c *Config) RateWindow() time.Duration {
+	return time.Duration(c.Display.RateWindowMs) * time.Millisecond
+}
+
+func (c *Config) CoachCooldown() time.Duration {
+	return time.Duration(c.Coaching.CooldownMs) * time.Millisecond
+}
+
+func (c *Config) HintDuration() time.Duration {
+	return time.Duration(c.Coaching.HintDurationMs) * time.Millisecond
+}
+
 func (c *Config) HeldKeyTimeout() time.Duration {
 	return time.Duration(c.Display.HeldKeyTimeoutMs) * time.Millisecond
 }
+
+func (c *Config) ChordWindow() time.Duration {
+	return time.Duration(c.Behavior.ChordWindowMs) * time.Millisecond
+}
+
+func (c *Config) StickyTimeout() time.Duration {
+	return time.Duration(c.Behavior.StickyTimeoutMs) * time.Millisecond
+}
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/tapshow/tapshow/internal/i18n"
 )
 
 func TestDefault(t *testing.T) {
@@ -30,6 +35,16 @@ func TestDefault(t *testing.T) {
 	if !cfg.Behavior.CombineModifiers {
 		t.Error("Default CombineModifiers should be true")
 	}
+
+	if cfg.Recall.Hotkey != "" {
+		t.Errorf("Default recall hotkey = %q, want none", cfg.Recall.Hotkey)
+	}
+
+	// Entry lifetimes default to timeout_ms.
+	if cfg.ShortcutLifetime() != 0 || cfg.SpecialLifetime() != 0 || cfg.PrintableLifetime() != 0 {
+		t.Errorf("Default lifetimes = %v %v %v, want 0 to use timeout_ms",
+			cfg.ShortcutLifetime(), cfg.SpecialLifetime(), cfg.PrintableLifetime())
+	}
 }
 
 func TestTimeout(t *testing.T) {
@@ -244,3 +259,200 @@ func TestExcludedKeys(t *testing.T) {
 		t.Errorf("Loaded ExcludedKeys length = %d, want 2", len(loaded.Behavior.ExcludedKeys))
 	}
 }
+
+func TestPrivacyConfigMaskOnApps(t *testing.T) {
+	configContent := `
+[privacy]
+mask_printable = true
+mask_on_apps = ["slack", { class = "discord" }]
+`
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	loaded, err := LoadFrom(configPath)
+	if err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+
+	if !loaded.Privacy.MaskPrintable {
+		t.Error("Loaded MaskPrintable should be true")
+	}
+	if len(loaded.Privacy.MaskOnApps) != 2 {
+		t.Fatalf("Loaded MaskOnApps length = %d, want 2", len(loaded.Privacy.MaskOnApps))
+	}
+	if loaded.Privacy.MaskOnApps[1].Class != "discord" {
+		t.Errorf("Second mask matcher class = %q, want %q", loaded.Privacy.MaskOnApps[1].Class, "discord")
+	}
+}
+
+func TestCoachingRules(t *testing.T) {
+	configContent := `
+[coaching]
+enabled = true
+
+[[coaching.rules]]
+keys = ["Home", "Shift+End"]
+apps = ["code"]
+hint = "Ctrl+L selects the whole line"
+`
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.toml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	loaded, err := LoadFrom(configPath)
+	if err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+
+	if !loaded.Coaching.Enabled {
+		t.Error("Loaded Coaching.Enabled should be true")
+	}
+	if len(loaded.Coaching.Rules) != 1 {
+		t.Fatalf("Loaded rules length = %d, want 1 (user rules replace the built-in ones)", len(loaded.Coaching.Rules))
+	}
+	rule := loaded.Coaching.Rules[0]
+	if len(rule.Keys) != 2 || rule.Keys[1] != "Shift+End" || rule.Apps[0] != "code" {
+		t.Errorf("Loaded rule = %+v", rule)
+	}
+}
+
+func TestRulesValidation(t *testing.T) {
+	tests := []struct {
+		rule  string
+		valid bool
+	}{
+		{`combo = "Ctrl+S"` + "\n" + `action = "annotate"` + "\n" + `label = "(save)"`, true},
+		{`class = "Navigation"` + "\n" + `action = "hide"`, true},
+		{`class = "unmodified_printable"` + "\n" + `action = "mask"`, true},
+		{`combo = "Esc"` + "\n" + `action = "extend"` + "\n" + `lifetime_ms = 5000`, true},
+		{`combo = "Esc"` + "\n" + `action = "hid"`, false},
+		{`combo = "Esc"`, false},
+		{`class = "letter"` + "\n" + `action = "hide"`, false},
+		{`combo = "Ctrl+S"` + "\n" + `action = "relabel"`, false},
+		{`combo = "Esc"` + "\n" + `action = "extend"`, false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		if err := os.WriteFile(path, []byte("[[rules]]\n"+tt.rule+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadFrom(path)
+		if (err == nil) != tt.valid {
+			t.Errorf("LoadFrom(%q) error = %v, want valid = %v", tt.rule, err, tt.valid)
+		}
+	}
+}
+
+func TestRateWindowValidation(t *testing.T) {
+	for _, window := range []string{"0", "-1"} {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		if err := os.WriteFile(path, []byte("[display]\nrate_window_ms = "+window+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFrom(path); err == nil {
+			t.Errorf("LoadFrom with rate_window_ms = %s succeeded, want error", window)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := `
+[display]
+timeout_ms = 1500
+
+[labels]
+Enter = "⏎"
+
+[[rules]]
+combo = "Ctrl+S"
+action = "annotate"
+label = "(save)"
+
+[profiles.screencast.display]
+timeout_ms = 4000
+
+[profiles.screencast.labels]
+Super = "❖"
+
+[profiles.quiet]
+rules = [{ class = "letters", action = "hide" }]
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+	if cfg.Display.TimeoutMs != 1500 || len(cfg.Labels) != 1 {
+		t.Errorf("main config changed by profiles: timeout %d, labels %v", cfg.Display.TimeoutMs, cfg.Labels)
+	}
+
+	profiles := cfg.Profiles()
+	if len(profiles) != 2 || profiles[0].Name != "screencast" || profiles[1].Name != "quiet" {
+		t.Fatalf("Profiles() = %v, want screencast and quiet", profiles)
+	}
+
+	screencast := profiles[0].Config
+	if screencast.Display.TimeoutMs != 4000 {
+		t.Errorf("screencast timeout_ms = %d, want 4000", screencast.Display.TimeoutMs)
+	}
+	if screencast.Labels["Enter"] != "⏎" || screencast.Labels["Super"] != "❖" {
+		t.Errorf("screencast labels = %v, want the main labels plus Super", screencast.Labels)
+	}
+	if len(screencast.Rules) != 1 || screencast.Rules[0].Combo != "Ctrl+S" {
+		t.Errorf("screencast rules = %v, want the main rules", screencast.Rules)
+	}
+
+	quiet := profiles[1].Config
+	if quiet.Display.TimeoutMs != 1500 {
+		t.Errorf("quiet timeout_ms = %d, want the main 1500", quiet.Display.TimeoutMs)
+	}
+	if len(quiet.Rules) != 1 || quiet.Rules[0].Action != "hide" {
+		t.Errorf("quiet rules = %v, want its own rules to replace the main ones", quiet.Rules)
+	}
+}
+
+func TestProfileValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "[profiles.broken.display]\nrate_window_ms = 0\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFrom(path); err == nil {
+		t.Error("LoadFrom with an invalid profile succeeded, want error")
+	}
+}
+
+func TestErrorsTranslated(t *testing.T) {
+	defer i18n.SetLocale(i18n.DefaultLocale, "")
+	if err := i18n.SetLocale("de", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[[rules]]\ncombo = \"Esc\"\naction = \"hid\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFrom(path)
+	if err == nil || !strings.HasPrefix(err.Error(), "ungültige Konfiguration: Regel 1: unbekannte Aktion") {
+		t.Errorf("LoadFrom() error = %v, want it in German", err)
+	}
+
+	if err := os.WriteFile(path, []byte("[display\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadFrom(path)
+	var parseErr toml.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Errorf("LoadFrom() error = %v, want it to wrap a toml.ParseError", err)
+	}
+}
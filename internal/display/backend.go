@@ -16,6 +16,12 @@ type Backend interface {
 
 	SetPaused(paused bool)
 
+	SetVisible(visible bool)
+
+	// ShowPrompt shows a line of text above the keys, e.g. a training
+	// question; an empty prompt hides it.
+	ShowPrompt(prompt string)
+
 	Run() error
 
 	Stop()
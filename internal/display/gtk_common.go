@@ -3,11 +3,13 @@ package display
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/diamondburned/gotk4/pkg/gdk/v4"
 	"github.com/diamondburned/gotk4/pkg/glib/v2"
 	"github.com/diamondburned/gotk4/pkg/gtk/v4"
 	"github.com/tapshow/tapshow/internal/config"
+	"github.com/tapshow/tapshow/internal/i18n"
 	"github.com/tapshow/tapshow/internal/processor"
 )
 
@@ -17,8 +19,13 @@ type GTKCommon struct {
 	app         *gtk.Application
 	window      *gtk.Window
 	keysBox     *gtk.Box
-	placeholder *gtk.Label
-	hasKeys     bool
+	armedBox    *gtk.Box
+	heldBox     *gtk.Box
+	recallBox   *gtk.Box
+	rateLabel   *gtk.Label
+	modLabels   map[string]*gtk.Label
+	hintLabel   *gtk.Label
+	promptLabel *gtk.Label
 	paused      bool
 	compositor  Compositor
 }
@@ -33,17 +40,65 @@ func (g *GTKCommon) BuildUI() *gtk.WindowHandle {
 	g.keysBox.SetHAlign(gtk.AlignCenter)
 	g.keysBox.SetHExpand(true)
 
-	g.placeholder = gtk.NewLabel("Listening for keystrokes...")
-	g.placeholder.AddCSSClass("placeholder")
-	g.keysBox.Append(g.placeholder)
+	g.showPlaceholder()
+
+	g.armedBox = gtk.NewBox(gtk.OrientationHorizontal, 4)
+	g.heldBox = gtk.NewBox(gtk.OrientationHorizontal, 4)
+
+	container := gtk.NewBox(gtk.OrientationHorizontal, 4)
+	container.SetHAlign(gtk.AlignCenter)
+	if g.cfg.Display.ShowModifierBar {
+		container.Append(g.buildModifierBar())
+	}
+	container.Append(g.keysBox)
+	container.Append(g.armedBox)
+	container.Append(g.heldBox)
+
+	if g.cfg.Display.ShowRate {
+		g.rateLabel = gtk.NewLabel(i18n.T("rate", 0, 0))
+		g.rateLabel.AddCSSClass("rate-counter")
+		container.Append(g.rateLabel)
+	}
+
+	g.recallBox = gtk.NewBox(gtk.OrientationVertical, 2)
+	g.recallBox.SetHAlign(gtk.AlignCenter)
+
+	g.hintLabel = gtk.NewLabel("")
+	g.hintLabel.AddCSSClass("coach-hint")
+	g.hintLabel.SetVisible(false)
+
+	g.promptLabel = gtk.NewLabel("")
+	g.promptLabel.AddCSSClass("prompt")
+	g.promptLabel.SetVisible(false)
+
+	outer := gtk.NewBox(gtk.OrientationVertical, 4)
+	outer.Append(g.promptLabel)
+	outer.Append(g.recallBox)
+	outer.Append(container)
+	outer.Append(g.hintLabel)
 
 	handle := gtk.NewWindowHandle()
-	handle.SetChild(g.keysBox)
+	handle.SetChild(outer)
 	handle.SetHAlign(gtk.AlignCenter)
 
 	return handle
 }
 
+// buildModifierBar creates the persistent row of modifier indicators.
+func (g *GTKCommon) buildModifierBar() *gtk.Box {
+	bar := gtk.NewBox(gtk.OrientationHorizontal, 2)
+	bar.AddCSSClass("modifier-bar")
+
+	g.modLabels = make(map[string]*gtk.Label)
+	for _, name := range []string{"Ctrl", "Alt", "Shift", "Super"} {
+		label := gtk.NewLabel(i18n.Key(name))
+		label.AddCSSClass("modifier-indicator")
+		bar.Append(label)
+		g.modLabels[name] = label
+	}
+	return bar
+}
+
 func (g *GTKCommon) ApplyCSS() {
 	css := g.generateCSS()
 
@@ -104,6 +159,88 @@ window {
 	opacity: 0.5;
 }
 
+@keyframes fade-out {
+	to {
+		opacity: 0;
+	}
+}
+
+.key-fading {
+	animation: fade-out %dms ease-in forwards;
+}
+
+.key-held {
+	border-style: dashed;
+}
+
+.key-armed {
+	border-style: dotted;
+	opacity: 0.8;
+}
+
+.chord-group {
+	border-radius: 8px;
+	padding: 2px;
+	border: 1px solid @borders;
+}
+
+.chord-group .key-frame {
+	margin: 0;
+}
+
+.focus-divider {
+	margin: 4px 6px;
+}
+
+.focus-label {
+	padding: 0 6px;
+	font-size: %dpx;
+	opacity: 0.6;
+	color: @theme_text_color;
+}
+
+.recall-age {
+	min-width: 4em;
+	font-size: %dpx;
+	opacity: 0.7;
+	color: @theme_text_color;
+}
+
+.modifier-indicator {
+	padding: 2px 6px;
+	border-radius: 4px;
+	font-size: %dpx;
+	opacity: 0.35;
+	color: @theme_text_color;
+}
+
+.modifier-active {
+	opacity: 1;
+	background: alpha(@theme_selected_bg_color, 0.6);
+}
+
+.prompt {
+	padding: 4px 10px;
+	font-size: %dpx;
+	font-weight: 600;
+	color: @theme_text_color;
+}
+
+.coach-hint {
+	padding: 4px 10px;
+	font-size: %dpx;
+	font-style: italic;
+	color: @theme_text_color;
+}
+
+.rate-counter {
+	padding: 0 8px;
+	font-size: %dpx;
+	font-variant-numeric: tabular-nums;
+	opacity: 0.8;
+	color: @theme_text_color;
+}
+
 .placeholder {
 	padding: 8px 14px;
 	font-size: %dpx;
@@ -119,13 +256,24 @@ window {
 		fallbackKeyBg,
 		fallbackKeyBg,
 		g.cfg.Appearance.FontSize,
+		processor.FadeDuration.Milliseconds(),
+		g.cfg.Appearance.FontSize-4,
+		g.cfg.Appearance.FontSize-4,
+		g.cfg.Appearance.FontSize-4,
+		g.cfg.Appearance.FontSize,
+		g.cfg.Appearance.FontSize-2,
+		g.cfg.Appearance.FontSize-4,
 		g.cfg.Appearance.FontSize-2,
 	)
 }
 
 func (g *GTKCommon) clearChildren() {
-	for child := g.keysBox.FirstChild(); child != nil; child = g.keysBox.FirstChild() {
-		g.keysBox.Remove(child)
+	clearBox(g.keysBox)
+}
+
+func clearBox(box *gtk.Box) {
+	for child := box.FirstChild(); child != nil; child = box.FirstChild() {
+		box.Remove(child)
 	}
 }
 
@@ -145,23 +293,95 @@ func (g *GTKCommon) createKeyWidget(text string, isRecent bool) *gtk.Frame {
 	return frame
 }
 
+// createEventWidget renders an event as a single keycap, or as a group of
+// keycaps when the event is a chord.
+func (g *GTKCommon) createEventWidget(event processor.DisplayEvent, isRecent bool) gtk.Widgetter {
+	if event.IsDivider {
+		return g.createDivider(event.Text)
+	}
+	if len(event.Keys) < 2 {
+		frame := g.createKeyWidget(event.Text, isRecent)
+		if event.IsFading {
+			frame.AddCSSClass("key-fading")
+		}
+		return frame
+	}
+
+	group := gtk.NewBox(gtk.OrientationHorizontal, 2)
+	group.AddCSSClass("chord-group")
+	if isRecent {
+		group.AddCSSClass("key-recent")
+	}
+	if event.IsFading {
+		group.AddCSSClass("key-fading")
+	}
+	for _, key := range event.Keys {
+		group.Append(g.createKeyWidget(key, false))
+	}
+
+	return group
+}
+
+// createDivider marks the start of a new segment after a focus change,
+// labelled with the app name when there is one.
+func (g *GTKCommon) createDivider(app string) gtk.Widgetter {
+	if app == "" {
+		sep := gtk.NewSeparator(gtk.OrientationVertical)
+		sep.AddCSSClass("focus-divider")
+		return sep
+	}
+	label := gtk.NewLabel(app)
+	label.AddCSSClass("focus-label")
+	return label
+}
+
 func (g *GTKCommon) ShowKey(event processor.DisplayEvent) {
 	glib.IdleAdd(func() {
 		g.mu.Lock()
 		defer g.mu.Unlock()
 
-		if g.paused || g.keysBox == nil {
+		if g.keysBox == nil {
+			return
+		}
+		// The indicators keep following the processor while paused, so the
+		// clears sent on pausing and modifier releases aren't lost.
+		if g.paused && !event.IsHeld && !event.IsArmed && !event.IsModBar {
 			return
 		}
 
-		if !g.hasKeys && g.placeholder != nil {
-			g.keysBox.Remove(g.placeholder)
-			g.placeholder = nil
-			g.hasKeys = true
+		if event.IsHeld {
+			g.showStatus(g.heldBox, event.Text, "key-held")
+			return
+		}
+		if event.IsArmed {
+			g.showStatus(g.armedBox, event.Text, "key-armed")
+			return
+		}
+		if event.IsHint {
+			g.hintLabel.SetText(event.Text)
+			g.hintLabel.SetVisible(event.Text != "")
+			if g.window != nil {
+				g.window.QueueResize()
+			}
+			return
+		}
+		if event.IsModBar {
+			g.showModifiers(event.Modifiers)
+			return
+		}
+		if event.IsStats {
+			if g.rateLabel != nil {
+				g.rateLabel.SetText(i18n.T("rate", event.KPM, event.WPM))
+			}
+			return
+		}
+		if event.IsRecall {
+			g.showRecall(event.Recall, event.Timestamp)
+			return
 		}
 
 		g.clearChildren()
-		g.keysBox.Append(g.createKeyWidget(event.Text, false))
+		g.keysBox.Append(g.createEventWidget(event, false))
 
 		if g.window != nil {
 			g.window.QueueResize()
@@ -169,6 +389,63 @@ func (g *GTKCommon) ShowKey(event processor.DisplayEvent) {
 	})
 }
 
+// showStatus replaces the indicator shown in box; an empty text removes it.
+func (g *GTKCommon) showStatus(box *gtk.Box, text, cssClass string) {
+	clearBox(box)
+	if text != "" {
+		frame := g.createKeyWidget(text, false)
+		frame.AddCSSClass(cssClass)
+		box.Append(frame)
+	}
+
+	if g.window != nil {
+		g.window.QueueResize()
+	}
+}
+
+// showModifiers lights up the indicators of the held modifiers.
+func (g *GTKCommon) showModifiers(held []string) {
+	for name, label := range g.modLabels {
+		label.RemoveCSSClass("modifier-active")
+		for _, h := range held {
+			if h == name {
+				label.AddCSSClass("modifier-active")
+			}
+		}
+	}
+}
+
+// showRecall lists recalled entries with how long ago they were pressed;
+// no entries hides the list.
+func (g *GTKCommon) showRecall(entries []processor.DisplayEvent, now time.Time) {
+	clearBox(g.recallBox)
+	for _, entry := range entries {
+		age := gtk.NewLabel(formatAgo(now.Sub(entry.Timestamp)))
+		age.AddCSSClass("recall-age")
+
+		row := gtk.NewBox(gtk.OrientationHorizontal, 6)
+		row.SetHAlign(gtk.AlignEnd)
+		row.Append(g.createEventWidget(entry, false))
+		row.Append(age)
+		g.recallBox.Append(row)
+	}
+
+	if g.window != nil {
+		g.window.QueueResize()
+	}
+}
+
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return i18n.T("now")
+	case d < time.Minute:
+		return i18n.T("seconds_ago", int(d.Seconds()))
+	default:
+		return i18n.T("minutes_ago", int(d.Minutes()))
+	}
+}
+
 func (g *GTKCommon) UpdateHistoryDisplay(events []processor.DisplayEvent) {
 	glib.IdleAdd(func() {
 		g.mu.Lock()
@@ -179,6 +456,13 @@ func (g *GTKCommon) UpdateHistoryDisplay(events []processor.DisplayEvent) {
 		}
 
 		g.clearChildren()
+		if len(events) == 0 {
+			g.showPlaceholder()
+			if g.window != nil {
+				g.window.QueueResize()
+			}
+			return
+		}
 
 		maxKeys := g.cfg.Display.HistoryCount
 		start := len(events) - maxKeys
@@ -188,7 +472,7 @@ func (g *GTKCommon) UpdateHistoryDisplay(events []processor.DisplayEvent) {
 
 		for i := start; i < len(events); i++ {
 			isRecent := i < len(events)-1
-			g.keysBox.Append(g.createKeyWidget(events[i].Text, isRecent))
+			g.keysBox.Append(g.createEventWidget(events[i], isRecent))
 		}
 
 		if g.window != nil {
@@ -203,6 +487,31 @@ func (g *GTKCommon) SetPausedState(paused bool) {
 	g.paused = paused
 }
 
+func (g *GTKCommon) ShowPromptText(prompt string) {
+	glib.IdleAdd(func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if g.promptLabel == nil {
+			return
+		}
+		g.promptLabel.SetText(prompt)
+		g.promptLabel.SetVisible(prompt != "")
+
+		if g.window != nil {
+			g.window.QueueResize()
+		}
+	})
+}
+
+func (g *GTKCommon) SetWindowVisible(visible bool) {
+	glib.IdleAdd(func() {
+		if g.window != nil {
+			g.window.SetVisible(visible)
+		}
+	})
+}
+
 func (g *GTKCommon) ResetDisplay() {
 	glib.IdleAdd(func() {
 		g.mu.Lock()
@@ -213,13 +522,19 @@ func (g *GTKCommon) ResetDisplay() {
 		}
 
 		g.clearChildren()
-		g.placeholder = gtk.NewLabel("Listening for keystrokes...")
-		g.placeholder.AddCSSClass("placeholder")
-		g.keysBox.Append(g.placeholder)
-		g.hasKeys = false
+		clearBox(g.armedBox)
+		clearBox(g.heldBox)
+		g.showPlaceholder()
 
 		if g.window != nil {
 			g.window.QueueResize()
 		}
 	})
 }
+
+// showPlaceholder puts the listening label into the emptied keys box.
+func (g *GTKCommon) showPlaceholder() {
+	placeholder := gtk.NewLabel(i18n.T("listening"))
+	placeholder.AddCSSClass("placeholder")
+	g.keysBox.Append(placeholder)
+}
@@ -40,6 +40,14 @@ func (g *GTKWindowBackend) SetPaused(paused bool) {
 	g.SetPausedState(paused)
 }
 
+func (g *GTKWindowBackend) SetVisible(visible bool) {
+	g.SetWindowVisible(visible)
+}
+
+func (g *GTKWindowBackend) ShowPrompt(prompt string) {
+	g.ShowPromptText(prompt)
+}
+
 func (g *GTKWindowBackend) Run() error {
 	g.app = gtk.NewApplication("ca.icewolf.tapshow", 0)
 
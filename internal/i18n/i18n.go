@@ -0,0 +1,168 @@
+// Package i18n translates key names and user-facing strings. Translations
+// are TOML files embedded in the binary, which files of the same name in the
+// user's locale directory extend or override.
+package i18n
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultLocale holds the strings every other locale falls back to.
+const DefaultLocale = "en"
+
+//go:embed locales/*.toml
+var embedded embed.FS
+
+// Catalog maps key names such as "Delete" and string IDs such as
+// "listening" to their translations.
+type Catalog struct {
+	Keys    map[string]string `toml:"keys"`
+	Strings map[string]string `toml:"strings"`
+}
+
+var current atomic.Pointer[Catalog]
+
+func init() {
+	c, _ := Load(DefaultLocale, "")
+	current.Store(c)
+}
+
+// Detect returns the locale from the environment, e.g. "de_DE.UTF-8".
+func Detect() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			return value
+		}
+	}
+	return DefaultLocale
+}
+
+// Load builds the catalog for a locale such as "de_DE.UTF-8" by layering
+// "en", "de" and "de_DE", each from the embedded files and then from dir.
+// An unknown locale returns the default catalog and an error.
+func Load(locale, dir string) (*Catalog, error) {
+	c := &Catalog{Keys: make(map[string]string), Strings: make(map[string]string)}
+	names := candidates(locale)
+	found := len(names) == 1 || strings.HasPrefix(locale, DefaultLocale+"_")
+	for i, name := range names {
+		ok, err := c.merge(name, dir)
+		if err != nil {
+			return c, err
+		}
+		found = found || (ok && i > 0)
+	}
+	if !found {
+		return c, fmt.Errorf("no translations for locale %q", locale)
+	}
+	return c, nil
+}
+
+// SetLocale loads a locale, falling back to Detect when it is empty, and
+// makes it the one used by T and Key. On error the default catalog is used.
+func SetLocale(locale, dir string) error {
+	if locale == "" {
+		locale = Detect()
+	}
+	c, err := Load(locale, dir)
+	current.Store(c)
+	return err
+}
+
+// T returns the translation of a string ID, formatted with args.
+func T(id string, args ...any) string {
+	s, ok := current.Load().Strings[id]
+	if !ok {
+		s = id
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(s, args...)
+	}
+	return s
+}
+
+// Key returns the translated name of a key or modifier.
+func Key(name string) string {
+	if k, ok := current.Load().Keys[name]; ok {
+		return k
+	}
+	return name
+}
+
+// KeyNames returns a copy of the translated key names.
+func KeyNames() map[string]string {
+	keys := current.Load().Keys
+	names := make(map[string]string, len(keys))
+	for k, v := range keys {
+		names[k] = v
+	}
+	return names
+}
+
+// candidates returns the locale names to layer, most general first.
+func candidates(locale string) []string {
+	name, _, _ := strings.Cut(locale, ".")
+	name, _, _ = strings.Cut(name, "@")
+	names := []string{DefaultLocale}
+	if name == "" || name == "C" || name == "POSIX" {
+		return names
+	}
+	lang, _, _ := strings.Cut(name, "_")
+	if lang != DefaultLocale {
+		names = append(names, lang)
+	}
+	if name != lang {
+		names = append(names, name)
+	}
+	return names
+}
+
+// merge applies the embedded and user files for one locale name, reporting
+// whether either exists.
+func (c *Catalog) merge(name, dir string) (bool, error) {
+	found := false
+	data, err := embedded.ReadFile("locales/" + name + ".toml")
+	if err == nil {
+		if err := c.decode(data, name); err != nil {
+			return false, err
+		}
+		found = true
+	}
+
+	if dir == "" {
+		return found, nil
+	}
+	data, err = os.ReadFile(filepath.Join(dir, name+".toml"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return found, nil
+	}
+	if err != nil {
+		return found, fmt.Errorf("reading locale %s: %w", name, err)
+	}
+	if err := c.decode(data, name); err != nil {
+		return found, err
+	}
+	return true, nil
+}
+
+func (c *Catalog) decode(data []byte, name string) error {
+	var layer Catalog
+	if _, err := toml.Decode(string(data), &layer); err != nil {
+		return fmt.Errorf("parsing locale %s: %w", name, err)
+	}
+	for k, v := range layer.Keys {
+		c.Keys[k] = v
+	}
+	for k, v := range layer.Strings {
+		c.Strings[k] = v
+	}
+	return nil
+}
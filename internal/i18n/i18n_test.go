@@ -0,0 +1,141 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCandidates(t *testing.T) {
+	tests := []struct {
+		locale   string
+		expected []string
+	}{
+		{"", []string{"en"}},
+		{"C", []string{"en"}},
+		{"C.UTF-8", []string{"en"}},
+		{"en_US.UTF-8", []string{"en", "en_US"}},
+		{"de_DE.UTF-8@euro", []string{"en", "de", "de_DE"}},
+		{"fr", []string{"en", "fr"}},
+	}
+
+	for _, tt := range tests {
+		if got := candidates(tt.locale); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("candidates(%q) = %q, want %q", tt.locale, got, tt.expected)
+		}
+	}
+}
+
+func TestEmbeddedLocales(t *testing.T) {
+	en, err := Load("en", "")
+	if err != nil {
+		t.Fatalf("Load(en) failed: %v", err)
+	}
+
+	entries, err := embedded.ReadDir("locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".toml")
+		c, err := Load(name, "")
+		if err != nil {
+			t.Errorf("Load(%s) failed: %v", name, err)
+			continue
+		}
+		for id, s := range c.Strings {
+			if _, ok := en.Strings[id]; !ok {
+				t.Errorf("%s: string %q is not in en", name, id)
+			}
+			if strings.Count(s, "%") != strings.Count(en.Strings[id], "%") {
+				t.Errorf("%s: %q = %q has different verbs than %q", name, id, s, en.Strings[id])
+			}
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	c, err := Load("de_DE.UTF-8", "")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Keys["Ctrl"] != "Strg" || c.Keys["PageUp"] != "Bild↑" {
+		t.Errorf("Keys = %v, want Strg and Bild↑", c.Keys)
+	}
+	if c.Strings["version_short"] == "" || c.Strings["version_short"] == "Print version information" {
+		t.Errorf("version_short = %q, want a German translation", c.Strings["version_short"])
+	}
+
+	c, err = Load("xx_YY", "")
+	if err == nil {
+		t.Error("Expected an error for an unknown locale")
+	}
+	if c.Strings["listening"] != "Listening for keystrokes..." {
+		t.Errorf("Unknown locale should fall back to English, got %q", c.Strings["listening"])
+	}
+}
+
+func TestLoadUserDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("de.toml", "[keys]\nCtrl = \"Ctrl\"\n")
+	write("nl.toml", "[keys]\nDelete = \"Del\"\n[strings]\nlistening = \"Wachten op toetsen...\"\n")
+
+	c, err := Load("de_DE", dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if c.Keys["Ctrl"] != "Ctrl" || c.Keys["Delete"] != "Entf" {
+		t.Errorf("User file should override only its own keys, got %v", c.Keys)
+	}
+
+	c, err = Load("nl_NL.UTF-8", dir)
+	if err != nil {
+		t.Fatalf("Load of a user-only locale failed: %v", err)
+	}
+	if c.Strings["listening"] != "Wachten op toetsen..." || c.Strings["stopped"] != "Stopped." {
+		t.Errorf("Strings = %v", c.Strings)
+	}
+
+	write("bad.toml", "[keys\n")
+	if _, err := Load("bad", dir); err == nil {
+		t.Error("Expected a parse error")
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	defer SetLocale(DefaultLocale, "")
+
+	if err := SetLocale("fr_FR.UTF-8", ""); err != nil {
+		t.Fatalf("SetLocale failed: %v", err)
+	}
+	if got := Key("Shift"); got != "Maj" {
+		t.Errorf("Key(Shift) = %q, want Maj", got)
+	}
+	if got := Key("Ctrl"); got != "Ctrl" {
+		t.Errorf("Key(Ctrl) = %q, want Ctrl", got)
+	}
+	if got := T("score", 3, 5); got != "Score : 3/5" {
+		t.Errorf("T(score) = %q", got)
+	}
+	if got := T("no_such_string"); got != "no_such_string" {
+		t.Errorf("T of an unknown ID = %q, want the ID", got)
+	}
+	if KeyNames()["Delete"] != "Suppr" {
+		t.Errorf("KeyNames = %v", KeyNames())
+	}
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "de_AT.UTF-8")
+	SetLocale("", "")
+	if got := T("stopped"); got != "Beendet." {
+		t.Errorf("T(stopped) with LANG=de_AT = %q, want Beendet.", got)
+	}
+}
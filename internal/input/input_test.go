@@ -105,3 +105,89 @@ func TestModifierFlags(t *testing.T) {
 		t.Error("Combined modifiers should not include ModAlt")
 	}
 }
+
+func TestGetKeyClass(t *testing.T) {
+	tests := []struct {
+		code     uint16
+		expected KeyClass
+	}{
+		{KEY_A, ClassLetter},
+		{KEY_M, ClassLetter},
+		{KEY_P, ClassLetter},
+		{KEY_1, ClassDigit},
+		{KEY_0, ClassDigit},
+		{KEY_SLASH, ClassPunctuation},
+		{KEY_GRAVE, ClassPunctuation},
+		{KEY_PAGEDOWN, ClassNavigation},
+		{KEY_F12, ClassFunction},
+		{KEY_KP7, ClassKeypad},
+		{KEY_LEFTCTRL, ClassModifier},
+		{KEY_ENTER, ClassSpecial},
+		{KEY_SPACE, ClassSpecial},
+	}
+
+	for _, tt := range tests {
+		if got := GetKeyClass(tt.code); got != tt.expected {
+			t.Errorf("GetKeyClass(%d) = %v, want %v", tt.code, got, tt.expected)
+		}
+	}
+}
+
+func TestParseKeyClass(t *testing.T) {
+	class, ok := ParseKeyClass(" Letters ")
+	if !ok || class != ClassLetter {
+		t.Errorf("ParseKeyClass(\" Letters \") = %v, %v; want %v, true", class, ok, ClassLetter)
+	}
+
+	if _, ok := ParseKeyClass("unknown"); ok {
+		t.Error("ParseKeyClass should reject unknown class names")
+	}
+}
+
+func TestIsPrintable(t *testing.T) {
+	for _, code := range []uint16{KEY_A, KEY_5, KEY_COMMA, KEY_SPACE, KEY_KP3} {
+		if !IsPrintable(code) {
+			t.Errorf("IsPrintable(%d) should be true", code)
+		}
+	}
+	for _, code := range []uint16{KEY_ENTER, KEY_ESC, KEY_F1, KEY_LEFT, KEY_KPENTER, KEY_LEFTSHIFT} {
+		if IsPrintable(code) {
+			t.Errorf("IsPrintable(%d) should be false", code)
+		}
+	}
+}
+
+func TestKeyChar(t *testing.T) {
+	tests := []struct {
+		code     uint16
+		shifted  bool
+		expected rune
+	}{
+		{KEY_A, false, 'a'},
+		{KEY_A, true, 'A'},
+		{KEY_4, false, '4'},
+		{KEY_4, true, '$'},
+		{KEY_APOSTROPHE, true, '"'},
+		{KEY_SPACE, false, ' '},
+	}
+
+	for _, tt := range tests {
+		got, ok := KeyChar(tt.code, tt.shifted)
+		if !ok || got != tt.expected {
+			t.Errorf("KeyChar(%d, %v) = %q, %v; want %q", tt.code, tt.shifted, got, ok, tt.expected)
+		}
+	}
+
+	if _, ok := KeyChar(KEY_ENTER, false); ok {
+		t.Error("KeyChar should not return a character for Enter")
+	}
+}
+
+func TestKeypadNavigation(t *testing.T) {
+	if nav, ok := KeypadNavigation(KEY_KP7); !ok || nav != KEY_HOME {
+		t.Errorf("KeypadNavigation(KEY_KP7) = %d, %v; want %d, true", nav, ok, KEY_HOME)
+	}
+	if _, ok := KeypadNavigation(KEY_KPPLUS); ok {
+		t.Error("KeypadNavigation(KEY_KPPLUS) should not map to a navigation key")
+	}
+}
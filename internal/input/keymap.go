@@ -226,3 +226,97 @@ var KeyNames = map[uint16]string{
 func GetKeyName(code uint16) string {
 	return KeyNames[code]
 }
+
+// keyChars maps keys to the characters they produce on a US layout,
+// unshifted and shifted.
+var keyChars = map[uint16][2]rune{
+	KEY_1:          {'1', '!'},
+	KEY_2:          {'2', '@'},
+	KEY_3:          {'3', '#'},
+	KEY_4:          {'4', '$'},
+	KEY_5:          {'5', '%'},
+	KEY_6:          {'6', '^'},
+	KEY_7:          {'7', '&'},
+	KEY_8:          {'8', '*'},
+	KEY_9:          {'9', '('},
+	KEY_0:          {'0', ')'},
+	KEY_MINUS:      {'-', '_'},
+	KEY_EQUAL:      {'=', '+'},
+	KEY_LEFTBRACE:  {'[', '{'},
+	KEY_RIGHTBRACE: {']', '}'},
+	KEY_SEMICOLON:  {';', ':'},
+	KEY_APOSTROPHE: {'\'', '"'},
+	KEY_GRAVE:      {'`', '~'},
+	KEY_BACKSLASH:  {'\\', '|'},
+	KEY_COMMA:      {',', '<'},
+	KEY_DOT:        {'.', '>'},
+	KEY_SLASH:      {'/', '?'},
+	KEY_SPACE:      {' ', ' '},
+	KEY_KP0:        {'0', '0'},
+	KEY_KP1:        {'1', '1'},
+	KEY_KP2:        {'2', '2'},
+	KEY_KP3:        {'3', '3'},
+	KEY_KP4:        {'4', '4'},
+	KEY_KP5:        {'5', '5'},
+	KEY_KP6:        {'6', '6'},
+	KEY_KP7:        {'7', '7'},
+	KEY_KP8:        {'8', '8'},
+	KEY_KP9:        {'9', '9'},
+	KEY_KPDOT:      {'.', '.'},
+	KEY_KPPLUS:     {'+', '+'},
+	KEY_KPMINUS:    {'-', '-'},
+	KEY_KPASTERISK: {'*', '*'},
+	KEY_KPSLASH:    {'/', '/'},
+	KEY_KPEQUAL:    {'=', '='},
+	KEY_KPCOMMA:    {',', ','},
+}
+
+// KeyChar returns the character a key types on a US layout. Letters are
+// derived from their key names.
+func KeyChar(code uint16, shifted bool) (rune, bool) {
+	if GetKeyClass(code) == ClassLetter {
+		r := rune(KeyNames[code][0])
+		if !shifted {
+			r += 'a' - 'A'
+		}
+		return r, true
+	}
+
+	chars, ok := keyChars[code]
+	if !ok {
+		return 0, false
+	}
+	if shifted {
+		return chars[1], true
+	}
+	return chars[0], true
+}
+
+var keypadNavigation = map[uint16]uint16{
+	KEY_KP7:   KEY_HOME,
+	KEY_KP8:   KEY_UP,
+	KEY_KP9:   KEY_PAGEUP,
+	KEY_KP4:   KEY_LEFT,
+	KEY_KP6:   KEY_RIGHT,
+	KEY_KP1:   KEY_END,
+	KEY_KP2:   KEY_DOWN,
+	KEY_KP3:   KEY_PAGEDOWN,
+	KEY_KP0:   KEY_INSERT,
+	KEY_KPDOT: KEY_DELETE,
+}
+
+// KeypadNavigation returns the key a keypad key acts as while NumLock is off.
+func KeypadNavigation(code uint16) (uint16, bool) {
+	nav, ok := keypadNavigation[code]
+	return nav, ok
+}
+
+// IsKeypadDigit reports whether the key is one of the keypad digits.
+func IsKeypadDigit(code uint16) bool {
+	switch code {
+	case KEY_KP0, KEY_KP1, KEY_KP2, KEY_KP3, KEY_KP4,
+		KEY_KP5, KEY_KP6, KEY_KP7, KEY_KP8, KEY_KP9:
+		return true
+	}
+	return false
+}
@@ -14,6 +14,7 @@ import (
 const (
 	EV_SYN         = 0x00
 	EV_KEY         = 0x01
+	LED_NUML       = 0x00
 	inputEventSize = 24
 )
 
@@ -67,6 +68,23 @@ func (r *Reader) Start() error {
 	return nil
 }
 
+// NumLock reports whether the NumLock LED is lit on any opened keyboard.
+func (r *Reader) NumLock() bool {
+	for _, f := range r.devices {
+		leds := make([]byte, 1)
+		_, _, errno := syscall.Syscall(
+			syscall.SYS_IOCTL,
+			f.Fd(),
+			uintptr(0x80014519), // EVIOCGLED(1)
+			uintptr(unsafe.Pointer(&leds[0])),
+		)
+		if errno == 0 && leds[0]&(1<<LED_NUML) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Reader) Stop() {
 	close(r.done)
 	for _, f := range r.devices {
@@ -114,11 +132,13 @@ func (r *Reader) readDevice(f *os.File) {
 			continue
 		}
 
+		// Use the kernel timestamp so that keys reported in the same
+		// SYN frame carry identical timestamps.
 		keyEvent := KeyEvent{
 			Code:      ev.Code,
 			Name:      name,
 			State:     state,
-			Timestamp: time.Now(),
+			Timestamp: time.Unix(ev.Time.Sec, ev.Time.Usec*1000),
 		}
 
 		select {
@@ -1,6 +1,9 @@
 package input
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type KeyState int
 
@@ -51,3 +54,86 @@ func GetModifier(code uint16) Modifier {
 	}
 	return ModNone
 }
+
+type KeyClass int
+
+const (
+	ClassSpecial KeyClass = iota
+	ClassModifier
+	ClassLetter
+	ClassDigit
+	ClassPunctuation
+	ClassNavigation
+	ClassFunction
+	ClassKeypad
+)
+
+var keyClassNames = map[KeyClass]string{
+	ClassSpecial:     "special",
+	ClassModifier:    "modifiers",
+	ClassLetter:      "letters",
+	ClassDigit:       "digits",
+	ClassPunctuation: "punctuation",
+	ClassNavigation:  "navigation",
+	ClassFunction:    "function",
+	ClassKeypad:      "keypad",
+}
+
+func (c KeyClass) String() string {
+	return keyClassNames[c]
+}
+
+// ParseKeyClass returns the class with the given config name, e.g. "letters".
+func ParseKeyClass(name string) (KeyClass, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for class, className := range keyClassNames {
+		if className == name {
+			return class, true
+		}
+	}
+	return ClassSpecial, false
+}
+
+func GetKeyClass(code uint16) KeyClass {
+	switch {
+	case IsModifier(code):
+		return ClassModifier
+	case code >= KEY_Q && code <= KEY_P,
+		code >= KEY_A && code <= KEY_L,
+		code >= KEY_Z && code <= KEY_M:
+		return ClassLetter
+	case code >= KEY_1 && code <= KEY_0:
+		return ClassDigit
+	}
+
+	switch code {
+	case KEY_MINUS, KEY_EQUAL, KEY_LEFTBRACE, KEY_RIGHTBRACE,
+		KEY_SEMICOLON, KEY_APOSTROPHE, KEY_GRAVE, KEY_BACKSLASH,
+		KEY_COMMA, KEY_DOT, KEY_SLASH:
+		return ClassPunctuation
+	case KEY_HOME, KEY_END, KEY_PAGEUP, KEY_PAGEDOWN,
+		KEY_UP, KEY_DOWN, KEY_LEFT, KEY_RIGHT:
+		return ClassNavigation
+	case KEY_F1, KEY_F2, KEY_F3, KEY_F4, KEY_F5, KEY_F6,
+		KEY_F7, KEY_F8, KEY_F9, KEY_F10, KEY_F11, KEY_F12:
+		return ClassFunction
+	case KEY_KP0, KEY_KP1, KEY_KP2, KEY_KP3, KEY_KP4,
+		KEY_KP5, KEY_KP6, KEY_KP7, KEY_KP8, KEY_KP9,
+		KEY_KPDOT, KEY_KPPLUS, KEY_KPMINUS, KEY_KPASTERISK,
+		KEY_KPSLASH, KEY_KPENTER, KEY_KPEQUAL, KEY_KPCOMMA:
+		return ClassKeypad
+	}
+	return ClassSpecial
+}
+
+// IsPrintable reports whether the key produces a character when typed
+// without Ctrl, Alt or Super.
+func IsPrintable(code uint16) bool {
+	switch GetKeyClass(code) {
+	case ClassLetter, ClassDigit, ClassPunctuation:
+		return true
+	case ClassKeypad:
+		return code != KEY_KPENTER
+	}
+	return code == KEY_SPACE
+}
@@ -0,0 +1,112 @@
+package privacy
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const hyprlandTimeout = time.Second
+
+// hyprlandSocket returns the path of one of Hyprland's sockets, which live in
+// $XDG_RUNTIME_DIR/hypr/<signature>, or /tmp/hypr/<signature> before 0.40.
+func hyprlandSocket(name string) (string, error) {
+	sig := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE")
+	if sig == "" {
+		return "", errors.New("HYPRLAND_INSTANCE_SIGNATURE not set")
+	}
+	path := filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "hypr", sig, name)
+	if _, err := os.Stat(path); err != nil {
+		legacy := filepath.Join("/tmp/hypr", sig, name)
+		if _, legacyErr := os.Stat(legacy); legacyErr == nil {
+			return legacy, nil
+		}
+		return "", err
+	}
+	return path, nil
+}
+
+// hyprlandRequest sends a command such as "j/activewindow" over the request
+// socket, the same way hyprctl does, and returns the reply.
+func hyprlandRequest(command string) ([]byte, error) {
+	path, err := hyprlandSocket(".socket.sock")
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dialUnix(path, hyprlandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(hyprlandTimeout))
+
+	if _, err := conn.Write([]byte(command)); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(conn)
+}
+
+func getHyprlandFocusedWindow() WindowInfo {
+	output, err := hyprlandRequest("j/activewindow")
+	if err != nil {
+		return WindowInfo{}
+	}
+
+	var window struct {
+		Class string `json:"class"`
+		Title string `json:"title"`
+		PID   int    `json:"pid"`
+	}
+	if err := json.Unmarshal(output, &window); err != nil {
+		return WindowInfo{}
+	}
+
+	procName, path := getProcessInfo(window.PID)
+	return WindowInfo{Class: window.Class, ProcessName: procName, Path: path, Title: window.Title}
+}
+
+// watchHyprland follows the socket2 event stream until Stop and checks the
+// focused window whenever it may have changed. It returns false if the
+// stream can't be opened or breaks.
+func (m *Monitor) watchHyprland() bool {
+	path, err := hyprlandSocket(".socket2.sock")
+	if err != nil {
+		return false
+	}
+	conn, err := dialUnix(path, hyprlandTimeout)
+	if err != nil {
+		return false
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-m.done:
+		case <-stop:
+		}
+		conn.Close()
+	}()
+
+	m.checkFocusedWindow()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	for scanner.Scan() {
+		event, _, _ := strings.Cut(scanner.Text(), ">>")
+		switch event {
+		case "activewindowv2", "closewindow", "workspace", "windowtitle":
+			m.checkFocusedWindow()
+		}
+	}
+
+	select {
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -3,6 +3,7 @@ package privacy
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"strconv"
@@ -29,6 +30,14 @@ func (w WindowInfo) IsEmpty() bool {
 	return w.Class == "" && w.ProcessName == "" && w.Path == "" && w.Title == ""
 }
 
+// App names the focused application, preferring the window class.
+func (w WindowInfo) App() string {
+	if w.Class != "" {
+		return w.Class
+	}
+	return w.ProcessName
+}
+
 func (w WindowInfo) MatchesAny(pattern string) bool {
 	if pattern == "" {
 		return false
@@ -71,16 +80,29 @@ func (w WindowInfo) Matches(m config.AppMatcher) bool {
 type Monitor struct {
 	mu             sync.RWMutex
 	matchers       config.AppMatchers
+	maskMatchers   config.AppMatchers
 	compositor     display.Compositor
 	paused         bool
+	masked         bool
 	done           chan struct{}
 	onChange       func(paused bool)
+	onMaskChange   func(masked bool)
+	onFocus        []func(info WindowInfo)
+	lastFocused    WindowInfo
 	resumeCooldown time.Duration
 	lastMatchAt    time.Time
+	lastMaskAt     time.Time
+	recheckTimer   *time.Timer
 }
 
 const defaultResumeCooldownMs = 500 * time.Millisecond
 
+// dialUnix connects to a compositor socket. Tests replace it to serve the
+// protocol over net.Pipe, which, unlike a socket, blocks durably in synctest.
+var dialUnix = func(path string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", path, timeout)
+}
+
 func NewMonitor(matchers config.AppMatchers, onChange func(paused bool)) *Monitor {
 	return &Monitor{
 		matchers:       matchers,
@@ -91,8 +113,22 @@ func NewMonitor(matchers config.AppMatchers, onChange func(paused bool)) *Monito
 	}
 }
 
+// WatchMask reports through onChange whether an app matching one of matchers
+// is focused, so that typing can be masked instead of pausing entirely.
+// It must be called before Start.
+func (m *Monitor) WatchMask(matchers config.AppMatchers, onChange func(masked bool)) {
+	m.maskMatchers = matchers
+	m.onMaskChange = onChange
+}
+
+// OnFocus registers fn to be called whenever the focused window changes,
+// including title changes. It must be called before Start.
+func (m *Monitor) OnFocus(fn func(info WindowInfo)) {
+	m.onFocus = append(m.onFocus, fn)
+}
+
 func (m *Monitor) Start() {
-	if len(m.matchers) == 0 {
+	if len(m.matchers) == 0 && len(m.maskMatchers) == 0 && len(m.onFocus) == 0 {
 		return
 	}
 
@@ -101,6 +137,11 @@ func (m *Monitor) Start() {
 
 func (m *Monitor) Stop() {
 	close(m.done)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.recheckTimer != nil {
+		m.recheckTimer.Stop()
+	}
 }
 
 func (m *Monitor) IsPaused() bool {
@@ -109,11 +150,21 @@ func (m *Monitor) IsPaused() bool {
 	return m.paused
 }
 
+func (m *Monitor) IsMasked() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.masked
+}
+
 func (m *Monitor) testCheckWindow(info WindowInfo) {
 	m.checkWindowInfo(info)
 }
 
 func (m *Monitor) monitorLoop() {
+	if m.watchEvents() {
+		return
+	}
+
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -127,6 +178,20 @@ func (m *Monitor) monitorLoop() {
 	}
 }
 
+// watchEvents follows focus changes through the compositor's event stream
+// until Stop. It returns false when there is no stream, or it broke, and the
+// monitor should poll instead.
+func (m *Monitor) watchEvents() bool {
+	switch m.compositor {
+	case display.CompositorSway:
+		return m.watchSway()
+	case display.CompositorHyprland:
+		return m.watchHyprland()
+	default:
+		return false
+	}
+}
+
 func (m *Monitor) checkFocusedWindow() {
 	info := GetFocusedWindow(m.compositor)
 	if info.IsEmpty() {
@@ -136,33 +201,95 @@ func (m *Monitor) checkFocusedWindow() {
 }
 
 func (m *Monitor) checkWindowInfo(info WindowInfo) {
-	shouldPause := false
-	for _, matcher := range m.matchers {
-		if info.Matches(matcher) {
-			shouldPause = true
-			break
+	shouldPause := matchesAny(info, m.matchers)
+	shouldMask := matchesAny(info, m.maskMatchers)
+
+	m.mu.Lock()
+	newPaused := m.applyCooldown(m.paused, shouldPause, &m.lastMatchAt)
+	newMasked := m.applyCooldown(m.masked, shouldMask, &m.lastMaskAt)
+
+	pauseChanged := m.paused != newPaused
+	maskChanged := m.masked != newMasked
+	focusChanged := m.lastFocused != info
+	m.paused = newPaused
+	m.masked = newMasked
+	m.lastFocused = info
+	m.scheduleRecheck(newPaused && !shouldPause, newMasked && !shouldMask)
+	m.mu.Unlock()
+
+	if focusChanged {
+		for _, fn := range m.onFocus {
+			fn(info)
 		}
 	}
 
-	m.mu.Lock()
-	if shouldPause {
-		m.lastMatchAt = time.Now()
+	if pauseChanged && m.onChange != nil {
+		m.onChange(newPaused)
+	}
+	if maskChanged && m.onMaskChange != nil {
+		m.onMaskChange(newMasked)
 	}
+}
 
-	newPaused := shouldPause
-	if m.paused && !shouldPause && m.resumeCooldown > 0 {
-		if time.Since(m.lastMatchAt) < m.resumeCooldown {
-			newPaused = true
+// scheduleRecheck checks the focused window again once a state kept only by
+// its cooldown may end. Event streams only report changes, so without this
+// a pause would last until the next focus event.
+func (m *Monitor) scheduleRecheck(pauseCooling, maskCooling bool) {
+	if m.recheckTimer != nil {
+		m.recheckTimer.Stop()
+		m.recheckTimer = nil
+	}
+	var wait time.Duration
+	if pauseCooling {
+		wait = m.resumeCooldown - time.Since(m.lastMatchAt)
+	}
+	if maskCooling {
+		if left := m.resumeCooldown - time.Since(m.lastMaskAt); wait <= 0 || left < wait {
+			wait = left
 		}
 	}
+	if wait <= 0 {
+		return
+	}
 
-	changed := m.paused != newPaused
-	m.paused = newPaused
-	m.mu.Unlock()
+	var t *time.Timer
+	t = time.AfterFunc(wait, func() {
+		m.mu.RLock()
+		current := m.recheckTimer == t
+		info := m.lastFocused
+		m.mu.RUnlock()
+		select {
+		case <-m.done:
+			return
+		default:
+		}
+		if current {
+			m.checkWindowInfo(info)
+		}
+	})
+	m.recheckTimer = t
+}
 
-	if changed && m.onChange != nil {
-		m.onChange(newPaused)
+// applyCooldown keeps a state active for resumeCooldown after its last match
+// so that brief focus changes don't flicker it off.
+func (m *Monitor) applyCooldown(active, matched bool, lastMatchAt *time.Time) bool {
+	if matched {
+		*lastMatchAt = time.Now()
+		return true
+	}
+	if active && m.resumeCooldown > 0 && time.Since(*lastMatchAt) < m.resumeCooldown {
+		return true
+	}
+	return false
+}
+
+func matchesAny(info WindowInfo, matchers config.AppMatchers) bool {
+	for _, matcher := range matchers {
+		if info.Matches(matcher) {
+			return true
+		}
 	}
+	return false
 }
 
 func GetFocusedWindow(compositor display.Compositor) WindowInfo {
@@ -195,74 +322,6 @@ func getProcessInfo(pid int) (name, path string) {
 	return name, path
 }
 
-func getSwayFocusedWindow() WindowInfo {
-	cmd := exec.Command("swaymsg", "-t", "get_tree")
-	output, err := cmd.Output()
-	if err != nil {
-		return WindowInfo{}
-	}
-
-	var tree swayTree
-	if err := json.Unmarshal(output, &tree); err != nil {
-		return WindowInfo{}
-	}
-
-	return findFocusedSway(&tree)
-}
-
-type swayTree struct {
-	Name          string     `json:"name"`
-	AppID         string     `json:"app_id"`
-	PID           int        `json:"pid"`
-	Focused       bool       `json:"focused"`
-	Nodes         []swayTree `json:"nodes"`
-	FloatingNodes []swayTree `json:"floating_nodes"`
-}
-
-func findFocusedSway(node *swayTree) WindowInfo {
-	if node.Focused && (node.AppID != "" || node.Name != "") {
-		class := node.AppID
-		if class == "" {
-			class = node.Name
-		}
-		procName, path := getProcessInfo(node.PID)
-		return WindowInfo{Class: class, ProcessName: procName, Path: path, Title: node.Name}
-	}
-
-	for i := range node.Nodes {
-		if result := findFocusedSway(&node.Nodes[i]); !result.IsEmpty() {
-			return result
-		}
-	}
-	for i := range node.FloatingNodes {
-		if result := findFocusedSway(&node.FloatingNodes[i]); !result.IsEmpty() {
-			return result
-		}
-	}
-
-	return WindowInfo{}
-}
-
-func getHyprlandFocusedWindow() WindowInfo {
-	cmd := exec.Command("hyprctl", "activewindow", "-j")
-	output, err := cmd.Output()
-	if err != nil {
-		return WindowInfo{}
-	}
-
-	var window struct {
-		Class string `json:"class"`
-		Title string `json:"title"`
-		PID   int    `json:"pid"`
-	}
-	if err := json.Unmarshal(output, &window); err != nil {
-		return WindowInfo{}
-	}
-
-	procName, path := getProcessInfo(window.PID)
-	return WindowInfo{Class: window.Class, ProcessName: procName, Path: path, Title: window.Title}
-}
-
 func getKDEFocusedWindow() WindowInfo {
 	winID := getKDEActiveWindowID()
 	if winID == "" {
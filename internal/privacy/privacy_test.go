@@ -1,11 +1,17 @@
 package privacy
 
 import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"testing/synctest"
 	"time"
 
 	"github.com/tapshow/tapshow/internal/config"
+	"github.com/tapshow/tapshow/internal/display"
 )
 
 func TestWindowInfo_MatchesAny(t *testing.T) {
@@ -259,7 +265,9 @@ func TestMonitor_ResumeCooldown(t *testing.T) {
 			t.Error("Monitor should remain paused during cooldown period")
 		}
 
+		// The cooldown ends on its own, from the recheck timer.
 		time.Sleep(defaultResumeCooldownMs)
+		synctest.Wait()
 
 		monitor.testCheckWindow(normalApp)
 		if monitor.IsPaused() {
@@ -277,6 +285,57 @@ func TestMonitor_ResumeCooldown(t *testing.T) {
 	})
 }
 
+func TestMonitor_WatchMask(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		monitor := NewMonitor(config.AppMatchers{{Value: "1password"}}, func(paused bool) {})
+
+		var maskChanges []bool
+		monitor.WatchMask(config.AppMatchers{{Class: "slack"}}, func(masked bool) {
+			maskChanges = append(maskChanges, masked)
+		})
+
+		monitor.testCheckWindow(WindowInfo{Class: "Slack"})
+		if !monitor.IsMasked() {
+			t.Error("Monitor should mask for matching app")
+		}
+		if monitor.IsPaused() {
+			t.Error("Mask matchers should not pause the display")
+		}
+
+		time.Sleep(defaultResumeCooldownMs)
+
+		monitor.testCheckWindow(WindowInfo{Class: "firefox"})
+		if monitor.IsMasked() {
+			t.Error("Monitor should unmask after cooldown period")
+		}
+
+		if len(maskChanges) != 2 || !maskChanges[0] || maskChanges[1] {
+			t.Errorf("Expected mask changes [true false], got %v", maskChanges)
+		}
+	})
+}
+
+func TestMonitor_OnFocus(t *testing.T) {
+	monitor := NewMonitor(config.AppMatchers{}, func(paused bool) {})
+
+	var focused []WindowInfo
+	monitor.OnFocus(func(info WindowInfo) {
+		focused = append(focused, info)
+	})
+
+	kitty := WindowInfo{Class: "kitty", Title: "nvim main.go"}
+	monitor.testCheckWindow(kitty)
+	monitor.testCheckWindow(kitty)
+	monitor.testCheckWindow(WindowInfo{Class: "kitty", Title: "zsh"})
+
+	if len(focused) != 2 {
+		t.Fatalf("Expected 2 focus changes, got %d: %v", len(focused), focused)
+	}
+	if focused[1].Title != "zsh" {
+		t.Errorf("Second focus change title = %q, want %q", focused[1].Title, "zsh")
+	}
+}
+
 func TestMatching_Integration(t *testing.T) {
 	matchers := config.AppMatchers{
 		{Value: "1password"},
@@ -331,3 +390,336 @@ func TestMatching_Integration(t *testing.T) {
 		})
 	}
 }
+
+// fakeSway answers GET_TREE with tree and SUBSCRIBE with success, then sends
+// every payload from events to the subscribed connection as a window event.
+func fakeSway(t *testing.T, tree string, events <-chan string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sway.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	t.Setenv("SWAYSOCK", path)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSway(conn, tree, events)
+		}
+	}()
+}
+
+// serveSway answers get_tree with tree and, once subscribed, writes every
+// payload from events as a window event.
+func serveSway(conn net.Conn, tree string, events <-chan string) {
+	c := &swayConn{conn: conn}
+	defer c.Close()
+	for {
+		msgType, _, err := c.read()
+		if err != nil {
+			return
+		}
+		switch msgType {
+		case swayGetTree:
+			c.send(swayGetTree, []byte(tree))
+		case swaySubscribe:
+			c.send(swaySubscribe, []byte(`{"success":true}`))
+			for payload := range events {
+				if c.send(swayWindowEvent, []byte(payload)) != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestMonitor_SwayEvents(t *testing.T) {
+	events := make(chan string)
+	defer close(events)
+	fakeSway(t, `{"nodes":[{"name":"zsh","app_id":"foot","focused":true}]}`, events)
+
+	paused := make(chan bool, 4)
+	monitor := NewMonitor(config.AppMatchers{{Value: "keepassxc"}}, func(p bool) { paused <- p })
+	monitor.compositor = display.CompositorSway
+	focused := make(chan WindowInfo, 4)
+	monitor.OnFocus(func(info WindowInfo) { focused <- info })
+	monitor.Start()
+	defer monitor.Stop()
+
+	expectFocus := func(class, title string) {
+		t.Helper()
+		select {
+		case info := <-focused:
+			if info.Class != class || info.Title != title {
+				t.Errorf("Focused = %+v, want %s %q", info, class, title)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for focus on %s", class)
+		}
+	}
+
+	expectFocus("foot", "zsh")
+
+	events <- `{"change":"title","container":{"name":"vim","app_id":"foot","focused":false}}`
+	events <- `{"change":"focus","container":{"name":"Passwords","app_id":"org.keepassxc.KeePassXC","focused":true}}`
+	expectFocus("org.keepassxc.KeePassXC", "Passwords")
+	select {
+	case p := <-paused:
+		if !p {
+			t.Error("Expected pause when keepassxc is focused")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for pause")
+	}
+
+	events <- `{"change":"title","container":{"name":"Unlock","app_id":"org.keepassxc.KeePassXC","focused":true}}`
+	expectFocus("org.keepassxc.KeePassXC", "Unlock")
+}
+
+func TestMonitor_SwayResumeAfterCooldown(t *testing.T) {
+	t.Setenv("SWAYSOCK", "sway.sock")
+	dial := dialUnix
+	defer func() { dialUnix = dial }()
+
+	synctest.Test(t, func(t *testing.T) {
+		events := make(chan string)
+		defer close(events)
+		dialUnix = func(string, time.Duration) (net.Conn, error) {
+			client, server := net.Pipe()
+			go serveSway(server, `{"nodes":[{"name":"zsh","app_id":"foot","focused":true}]}`, events)
+			return client, nil
+		}
+
+		monitor := NewMonitor(config.AppMatchers{{Value: "keepassxc"}}, func(paused bool) {})
+		monitor.compositor = display.CompositorSway
+		monitor.Start()
+		defer monitor.Stop()
+		synctest.Wait()
+
+		events <- `{"change":"focus","container":{"name":"Passwords","app_id":"org.keepassxc.KeePassXC","focused":true}}`
+		synctest.Wait()
+		if !monitor.IsPaused() {
+			t.Fatal("Expected pause when keepassxc is focused")
+		}
+
+		events <- `{"change":"focus","container":{"name":"zsh","app_id":"foot","focused":true}}`
+		synctest.Wait()
+		if !monitor.IsPaused() {
+			t.Error("Monitor should remain paused during cooldown period")
+		}
+
+		time.Sleep(defaultResumeCooldownMs)
+		synctest.Wait()
+		if monitor.IsPaused() {
+			t.Error("Monitor should resume once the cooldown ends, without further events")
+		}
+	})
+}
+
+func TestMonitor_SwayWithoutSocket(t *testing.T) {
+	t.Setenv("SWAYSOCK", "")
+	monitor := NewMonitor(config.AppMatchers{}, func(paused bool) {})
+	monitor.compositor = display.CompositorSway
+	if monitor.watchEvents() {
+		t.Error("watchEvents should fail without SWAYSOCK so the monitor polls")
+	}
+	if info := getSwayFocusedWindow(); !info.IsEmpty() {
+		t.Errorf("getSwayFocusedWindow without SWAYSOCK = %+v", info)
+	}
+}
+
+// fakeHyprland serves the active window from activeWindow on the request
+// socket and writes every line from events to socket2 clients.
+func fakeHyprland(t *testing.T, activeWindow func() string, events <-chan string) {
+	t.Helper()
+	runtime := t.TempDir()
+	dir := filepath.Join(runtime, "hypr", "test")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "test")
+
+	listen := func(name string, handle func(net.Conn)) {
+		ln, err := net.Listen("unix", filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go handle(conn)
+			}
+		}()
+	}
+
+	listen(".socket.sock", func(conn net.Conn) { serveHyprlandRequest(conn, activeWindow) })
+	listen(".socket2.sock", func(conn net.Conn) { serveHyprlandEvents(conn, events) })
+}
+
+// serveHyprlandRequest answers j/activewindow with activeWindow.
+func serveHyprlandRequest(conn net.Conn, activeWindow func() string) {
+	defer conn.Close()
+	buf := make([]byte, 64)
+	n, _ := conn.Read(buf)
+	if string(buf[:n]) == "j/activewindow" {
+		conn.Write([]byte(activeWindow()))
+	}
+}
+
+// serveHyprlandEvents writes every line from events as a socket2 event.
+func serveHyprlandEvents(conn net.Conn, events <-chan string) {
+	defer conn.Close()
+	w := bufio.NewWriter(conn)
+	for line := range events {
+		w.WriteString(line + "\n")
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+func TestMonitor_HyprlandEvents(t *testing.T) {
+	var mu sync.Mutex
+	active := `{"class":"kitty","title":"zsh","pid":0}`
+	setActive := func(window string) {
+		mu.Lock()
+		active = window
+		mu.Unlock()
+	}
+	events := make(chan string)
+	defer close(events)
+	fakeHyprland(t, func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return active
+	}, events)
+
+	if info := getHyprlandFocusedWindow(); info.Class != "kitty" || info.Title != "zsh" {
+		t.Fatalf("getHyprlandFocusedWindow = %+v, want kitty zsh", info)
+	}
+
+	monitor := NewMonitor(config.AppMatchers{{Value: "keepassxc"}}, func(paused bool) {})
+	monitor.compositor = display.CompositorHyprland
+	focused := make(chan WindowInfo, 4)
+	monitor.OnFocus(func(info WindowInfo) { focused <- info })
+	monitor.Start()
+	defer monitor.Stop()
+
+	expectFocus := func(class string) {
+		t.Helper()
+		select {
+		case info := <-focused:
+			if info.Class != class {
+				t.Errorf("Focused = %+v, want %s", info, class)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for focus on %s", class)
+		}
+	}
+
+	expectFocus("kitty")
+
+	setActive(`{"class":"org.keepassxc.KeePassXC","title":"Passwords","pid":0}`)
+	events <- "openlayer>>overlay"
+	events <- "activewindowv2>>55d1c0ffee00"
+	expectFocus("org.keepassxc.KeePassXC")
+	if !monitor.IsPaused() {
+		t.Error("Expected pause when keepassxc is focused")
+	}
+
+	setActive(`{"class":"firefox","title":"Docs","pid":0}`)
+	events <- "workspace>>2"
+	expectFocus("firefox")
+}
+
+func TestMonitor_HyprlandResumeAfterCooldown(t *testing.T) {
+	runtime := t.TempDir()
+	dir := filepath.Join(runtime, "hypr", "test")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{".socket.sock", ".socket2.sock"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("XDG_RUNTIME_DIR", runtime)
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "test")
+	dial := dialUnix
+	defer func() { dialUnix = dial }()
+
+	synctest.Test(t, func(t *testing.T) {
+		var mu sync.Mutex
+		active := `{"class":"kitty","title":"zsh","pid":0}`
+		setActive := func(window string) {
+			mu.Lock()
+			active = window
+			mu.Unlock()
+		}
+		activeWindow := func() string {
+			mu.Lock()
+			defer mu.Unlock()
+			return active
+		}
+		events := make(chan string)
+		defer close(events)
+		dialUnix = func(path string, _ time.Duration) (net.Conn, error) {
+			client, server := net.Pipe()
+			if filepath.Base(path) == ".socket2.sock" {
+				go serveHyprlandEvents(server, events)
+			} else {
+				go serveHyprlandRequest(server, activeWindow)
+			}
+			return client, nil
+		}
+
+		monitor := NewMonitor(config.AppMatchers{{Value: "keepassxc"}}, func(paused bool) {})
+		monitor.compositor = display.CompositorHyprland
+		monitor.Start()
+		defer monitor.Stop()
+		synctest.Wait()
+
+		setActive(`{"class":"org.keepassxc.KeePassXC","title":"Passwords","pid":0}`)
+		events <- "activewindowv2>>55d1c0ffee00"
+		synctest.Wait()
+		if !monitor.IsPaused() {
+			t.Fatal("Expected pause when keepassxc is focused")
+		}
+
+		setActive(`{"class":"firefox","title":"Docs","pid":0}`)
+		events <- "activewindowv2>>55d1c0ffee01"
+		synctest.Wait()
+		if !monitor.IsPaused() {
+			t.Error("Monitor should remain paused during cooldown period")
+		}
+
+		time.Sleep(defaultResumeCooldownMs)
+		synctest.Wait()
+		if monitor.IsPaused() {
+			t.Error("Monitor should resume once the cooldown ends, without further events")
+		}
+	})
+}
+
+func TestMonitor_HyprlandWithoutSocket(t *testing.T) {
+	t.Setenv("HYPRLAND_INSTANCE_SIGNATURE", "missing")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	monitor := NewMonitor(config.AppMatchers{}, func(paused bool) {})
+	monitor.compositor = display.CompositorHyprland
+	if monitor.watchEvents() {
+		t.Error("watchEvents should fail without socket2 so the monitor polls")
+	}
+	if info := getHyprlandFocusedWindow(); !info.IsEmpty() {
+		t.Errorf("getHyprlandFocusedWindow without a socket = %+v", info)
+	}
+}
@@ -0,0 +1,211 @@
+package privacy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// sway/i3 IPC: each message is the magic string, the payload length and the
+// message type as native-endian uint32s, then the payload.
+const (
+	swayMagic       = "i3-ipc"
+	swayHeaderLen   = len(swayMagic) + 8
+	swaySubscribe   = 2
+	swayGetTree     = 4
+	swayEventFlag   = 1 << 31
+	swayWindowEvent = swayEventFlag | 3
+	swayDialTimeout = time.Second
+)
+
+type swayConn struct {
+	conn net.Conn
+}
+
+func dialSway() (*swayConn, error) {
+	path := os.Getenv("SWAYSOCK")
+	if path == "" {
+		return nil, errors.New("SWAYSOCK not set")
+	}
+	conn, err := dialUnix(path, swayDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &swayConn{conn: conn}, nil
+}
+
+func (c *swayConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *swayConn) send(msgType uint32, payload []byte) error {
+	msg := make([]byte, swayHeaderLen, swayHeaderLen+len(payload))
+	copy(msg, swayMagic)
+	binary.NativeEndian.PutUint32(msg[len(swayMagic):], uint32(len(payload)))
+	binary.NativeEndian.PutUint32(msg[len(swayMagic)+4:], msgType)
+	_, err := c.conn.Write(append(msg, payload...))
+	return err
+}
+
+func (c *swayConn) read() (uint32, []byte, error) {
+	header := make([]byte, swayHeaderLen)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return 0, nil, err
+	}
+	if string(header[:len(swayMagic)]) != swayMagic {
+		return 0, nil, errors.New("invalid sway IPC header")
+	}
+	length := binary.NativeEndian.Uint32(header[len(swayMagic):])
+	msgType := binary.NativeEndian.Uint32(header[len(swayMagic)+4:])
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, payload); err != nil {
+		return 0, nil, err
+	}
+	return msgType, payload, nil
+}
+
+// request sends a message and returns the reply, skipping any events that
+// arrive first.
+func (c *swayConn) request(msgType uint32, payload []byte) ([]byte, error) {
+	if err := c.send(msgType, payload); err != nil {
+		return nil, err
+	}
+	for {
+		t, reply, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		if t == msgType {
+			return reply, nil
+		}
+	}
+}
+
+// subscribeSway opens a connection that receives the given event types.
+func subscribeSway(events ...string) (*swayConn, error) {
+	c, err := dialSway()
+	if err != nil {
+		return nil, err
+	}
+	payload, _ := json.Marshal(events)
+	reply, err := c.request(swaySubscribe, payload)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(reply, &result); err != nil || !result.Success {
+		c.Close()
+		return nil, fmt.Errorf("sway subscribe failed: %s", reply)
+	}
+	return c, nil
+}
+
+func getSwayFocusedWindow() WindowInfo {
+	c, err := dialSway()
+	if err != nil {
+		return WindowInfo{}
+	}
+	defer c.Close()
+
+	reply, err := c.request(swayGetTree, nil)
+	if err != nil {
+		return WindowInfo{}
+	}
+
+	var tree swayTree
+	if err := json.Unmarshal(reply, &tree); err != nil {
+		return WindowInfo{}
+	}
+
+	return findFocusedSway(&tree)
+}
+
+type swayTree struct {
+	Name          string     `json:"name"`
+	AppID         string     `json:"app_id"`
+	PID           int        `json:"pid"`
+	Focused       bool       `json:"focused"`
+	Nodes         []swayTree `json:"nodes"`
+	FloatingNodes []swayTree `json:"floating_nodes"`
+}
+
+func (n *swayTree) info() WindowInfo {
+	class := n.AppID
+	if class == "" {
+		class = n.Name
+	}
+	procName, path := getProcessInfo(n.PID)
+	return WindowInfo{Class: class, ProcessName: procName, Path: path, Title: n.Name}
+}
+
+func findFocusedSway(node *swayTree) WindowInfo {
+	if node.Focused && (node.AppID != "" || node.Name != "") {
+		return node.info()
+	}
+
+	for i := range node.Nodes {
+		if result := findFocusedSway(&node.Nodes[i]); !result.IsEmpty() {
+			return result
+		}
+	}
+	for i := range node.FloatingNodes {
+		if result := findFocusedSway(&node.FloatingNodes[i]); !result.IsEmpty() {
+			return result
+		}
+	}
+
+	return WindowInfo{}
+}
+
+// watchSway follows window events until Stop, so a newly focused window is
+// checked at once. It returns false if the subscription fails or breaks.
+func (m *Monitor) watchSway() bool {
+	c, err := subscribeSway("window")
+	if err != nil {
+		return false
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-m.done:
+		case <-stop:
+		}
+		c.Close()
+	}()
+
+	m.checkFocusedWindow()
+	for {
+		msgType, payload, err := c.read()
+		if err != nil {
+			select {
+			case <-m.done:
+				return true
+			default:
+				return false
+			}
+		}
+		if msgType != swayWindowEvent {
+			continue
+		}
+
+		var event struct {
+			Change    string   `json:"change"`
+			Container swayTree `json:"container"`
+		}
+		if err := json.Unmarshal(payload, &event); err != nil {
+			continue
+		}
+		if (event.Change == "focus" || event.Change == "title") && event.Container.Focused {
+			m.checkWindowInfo(event.Container.info())
+		}
+	}
+}
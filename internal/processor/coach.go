@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"strings"
+	"time"
+)
+
+// CoachRule suggests a better shortcut when Keys, repeated Repeat times, are
+// pressed in a row, e.g. Left fifteen times or Home followed by Shift+End.
+type CoachRule struct {
+	Keys   []string
+	Repeat int      // zero or one matches Keys once
+	Apps   []string // only while one of these apps is focused; empty for any
+	Hint   string
+}
+
+type coachState struct {
+	rules     []CoachRule
+	lastFired []time.Time
+	maxLen    int
+	hintTimer *time.Timer
+}
+
+func newCoachState(rules []CoachRule) coachState {
+	c := coachState{lastFired: make([]time.Time, len(rules))}
+	for _, rule := range rules {
+		keys := make([]string, len(rule.Keys))
+		for i, key := range rule.Keys {
+			keys[i] = normalizeKeyCombo(key)
+		}
+		rule.Keys = keys
+		rule.Repeat = max(rule.Repeat, 1)
+		c.maxLen = max(c.maxLen, len(keys)*rule.Repeat)
+		c.rules = append(c.rules, rule)
+	}
+	return c
+}
+
+// coach records a key press and shows the hint of the first rule it
+// completes. Each rule stays quiet for CoachCooldown after it fired.
+func (p *Processor) coach(keyName string) {
+	if !p.config.Coaching || p.coaching.maxLen == 0 {
+		return
+	}
+
+	if len(p.recent) >= p.coaching.maxLen {
+		p.recent = p.recent[1:]
+	}
+	p.recent = append(p.recent, p.pressedCombo(keyName))
+
+	now := time.Now()
+	for i, rule := range p.coaching.rules {
+		last := p.coaching.lastFired[i]
+		if !last.IsZero() && now.Sub(last) < p.config.CoachCooldown {
+			continue
+		}
+		if !p.appMatches(rule.Apps) || !rule.matches(p.recent) {
+			continue
+		}
+		p.coaching.lastFired[i] = now
+		p.recent = p.recent[:0]
+		p.showHint(rule.Hint)
+		return
+	}
+}
+
+// matches reports whether recent ends with the rule's keys.
+func (r CoachRule) matches(recent []string) bool {
+	n := len(r.Keys) * r.Repeat
+	if n == 0 || len(recent) < n {
+		return false
+	}
+	tail := recent[len(recent)-n:]
+	for i, combo := range tail {
+		if combo != r.Keys[i%len(r.Keys)] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Processor) appMatches(apps []string) bool {
+	if len(apps) == 0 {
+		return true
+	}
+	focused := strings.ToLower(p.focusApp)
+	for _, app := range apps {
+		if app != "" && strings.Contains(focused, strings.ToLower(app)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShowHint shows text in the hint line for HintDuration, e.g. the name of
+// the profile switched to.
+func (p *Processor) ShowHint(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.showHint(text)
+}
+
+// showHint shows a coaching hint for HintDuration. An empty text tells the
+// display to hide it again.
+func (p *Processor) showHint(hint string) {
+	if p.coaching.hintTimer != nil {
+		p.coaching.hintTimer.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(p.config.HintDuration, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.coaching.hintTimer == t {
+			p.coaching.hintTimer = nil
+			p.publish(DisplayEvent{IsHint: true, Timestamp: time.Now()})
+		}
+	})
+	p.coaching.hintTimer = t
+
+	p.publish(DisplayEvent{Text: hint, IsHint: true, Timestamp: time.Now()})
+}
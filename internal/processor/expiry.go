@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"time"
+
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+// FadeDuration is how long before expiring an entry is marked IsFading, so
+// the display can fade it out.
+const FadeDuration = 400 * time.Millisecond
+
+type entryKind int
+
+const (
+	kindSpecial entryKind = iota
+	kindShortcut
+	kindPrintable
+)
+
+// keyKind classifies a key press with the current modifiers for its
+// history lifetime.
+func (p *Processor) keyKind(code uint16) entryKind {
+	switch {
+	case p.modifiers&shortcutMods != 0:
+		return kindShortcut
+	case input.IsPrintable(code):
+		return kindPrintable
+	}
+	return kindSpecial
+}
+
+// lifetime returns how long an entry of the given kind stays in the history;
+// zero keeps it until it is pushed out by newer entries.
+func (p *Processor) lifetime(kind entryKind) time.Duration {
+	var d time.Duration
+	switch kind {
+	case kindShortcut:
+		d = p.config.ShortcutLifetime
+	case kindPrintable:
+		d = p.config.PrintableLifetime
+	default:
+		d = p.config.SpecialLifetime
+	}
+	if d == 0 {
+		d = p.config.ResetTimeout
+	}
+	return d
+}
+
+// touch restarts the lifetime of a history entry.
+func (e *DisplayEvent) touch(now time.Time) {
+	e.IsFading = false
+	e.expires = time.Time{}
+	if e.lifetime > 0 {
+		e.expires = now.Add(e.lifetime)
+	}
+}
+
+// touchEntry restarts the lifetime of the entry with the given ID, e.g. when
+// the key that produced it is released.
+func (p *Processor) touchEntry(id uint64) {
+	for i := range p.history {
+		if p.history[i].ID == id {
+			p.history[i].touch(time.Now())
+			p.scheduleExpiry()
+			return
+		}
+	}
+}
+
+func (e *DisplayEvent) fadeAt() time.Time {
+	return e.expires.Add(-min(FadeDuration, e.lifetime/2))
+}
+
+// isPinned reports whether an entry belongs to a key that is still down, in
+// which case it does not expire until the key is released.
+func (p *Processor) isPinned(id uint64) bool {
+	for _, hk := range p.held {
+		if hk.entryID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleExpiry arms a timer for the next entry that starts fading or
+// expires.
+func (p *Processor) scheduleExpiry() {
+	if p.expiryTimer != nil {
+		p.expiryTimer.Stop()
+		p.expiryTimer = nil
+	}
+
+	var next time.Time
+	for i := range p.history {
+		e := &p.history[i]
+		if e.expires.IsZero() || p.isPinned(e.ID) {
+			continue
+		}
+		at := e.expires
+		if !e.IsFading {
+			at = e.fadeAt()
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(time.Until(next), func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.expiryTimer == t {
+			p.expire()
+		}
+	})
+	p.expiryTimer = t
+}
+
+// expire fades and removes entries whose lifetime is up. Once the history is
+// empty and no status indicator is shown, the display is reset.
+func (p *Processor) expire() {
+	now := time.Now()
+	kept := p.history[:0]
+	var fading []DisplayEvent
+	removed := false
+	for _, e := range p.history {
+		switch {
+		case e.expires.IsZero() || p.isPinned(e.ID):
+		case !now.Before(e.expires):
+			removed = true
+			continue
+		case !e.IsFading && !now.Before(e.fadeAt()):
+			e.IsFading = true
+			fading = append(fading, e)
+		}
+		kept = append(kept, e)
+	}
+	p.history = kept
+
+	// Send only once the history is rebuilt, so the snapshots include the
+	// fading flags.
+	for _, e := range fading {
+		p.send(e)
+	}
+
+	switch {
+	case !removed:
+	case len(p.history) == 0 && len(p.held) == 0 && p.sticky == nil:
+		p.send(DisplayEvent{IsReset: true, Timestamp: now})
+	default:
+		p.send(DisplayEvent{IsExpired: true, Timestamp: now})
+	}
+	p.scheduleExpiry()
+}
@@ -0,0 +1,49 @@
+package processor
+
+import "time"
+
+// Focus divider styles shown at the start of a new history segment.
+const (
+	DividerNone  = "none"
+	DividerLine  = "line"
+	DividerLabel = "label"
+)
+
+// FocusChanged records the focused app for app-specific coaching rules. With
+// Config.ResetOnFocusChange, it also starts a new history segment when the
+// app changes, so keys typed in the previous app are not shown as if typed
+// in the new one.
+func (p *Processor) FocusChanged(app string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if app == p.focusApp {
+		return
+	}
+	first := p.focusApp == ""
+	p.focusApp = app
+	p.recent = p.recent[:0]
+	if first || !p.config.ResetOnFocusChange {
+		return
+	}
+
+	// Drop rather than flush a pending chord, which would only flash up
+	// before the reset below clears it.
+	if p.chord != nil {
+		p.chord.timer.Stop()
+		p.chord = nil
+	}
+	p.sequence = nil
+	p.modal.clearPending()
+
+	p.history = p.history[:0]
+	p.scheduleExpiry()
+	p.send(DisplayEvent{IsReset: true, Timestamp: time.Now()})
+
+	switch p.config.FocusDivider {
+	case DividerLine:
+		p.publish(DisplayEvent{IsDivider: true, Timestamp: time.Now(), lifetime: p.lifetime(kindSpecial)})
+	case DividerLabel:
+		p.publish(DisplayEvent{Text: app, IsDivider: true, Timestamp: time.Now(), lifetime: p.lifetime(kindSpecial)})
+	}
+}
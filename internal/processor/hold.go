@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+)
+
+// holdTickInterval is how often the live hold duration is refreshed.
+const holdTickInterval = 100 * time.Millisecond
+
+// scheduleHoldTick refreshes the held indicator until no keys are held, so
+// its duration counts up while the hold continues.
+func (p *Processor) scheduleHoldTick() {
+	if p.holdTimer != nil {
+		return
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(holdTickInterval, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.holdTimer != t {
+			return
+		}
+		p.holdTimer = nil
+		for _, hk := range p.held {
+			if hk.held {
+				p.emitHeld()
+				p.scheduleHoldTick()
+				return
+			}
+		}
+	})
+	p.holdTimer = t
+}
+
+func formatHold(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+// Hotkey actions handled outside the processor through OnHotkey.
+const (
+	ActionToggleOverlay = "toggle_overlay"
+	ActionTogglePause   = "toggle_pause"
+	ActionClearHistory  = "clear_history"
+	ActionCycleProfile  = "cycle_profile"
+)
+
+// OnHotkey registers fn to be called with the action of a configured hotkey
+// when it is pressed. fn runs on its own goroutine so it may call back into
+// the processor. It must be called before Process.
+func (p *Processor) OnHotkey(fn func(action string)) {
+	p.onHotkey = fn
+}
+
+// SetPaused stops showing and recording keys, e.g. while a sensitive app is
+// focused. Hotkeys keep working while paused.
+func (p *Processor) SetPaused(paused bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused == paused {
+		return
+	}
+	p.paused = paused
+	if !paused {
+		p.rate.resume(time.Now())
+		if p.config.ShowRate && len(p.rate.keys) > 0 {
+			// Restart the ticks stopped by the pause.
+			p.emitRate()
+		}
+		return
+	}
+
+	p.rate.pause(time.Now())
+	p.dropPending()
+	for _, hk := range p.held {
+		hk.timer.Stop()
+	}
+	if len(p.held) > 0 {
+		p.held = nil
+		p.emitHeld()
+	}
+}
+
+// dropPending discards keys still waiting to be shown as part of a chord,
+// sequence, modal command or sticky combo.
+func (p *Processor) dropPending() {
+	if p.chord != nil {
+		p.chord.timer.Stop()
+		p.chord = nil
+	}
+	p.sequence = nil
+	p.modal.clearPending()
+	p.disarm()
+}
+
+// pressedCombo returns the normalized combo of a key pressed with the current
+// modifiers, regardless of CombineModifiers.
+func (p *Processor) pressedCombo(keyName string) string {
+	return normalizeKeyCombo(strings.Join(append(modifierNames(p.modifiers), keyName), "+"))
+}
+
+// handleHotkey runs the action bound to a key press, if any. Hotkeys are
+// swallowed so they never show up on the overlay.
+func (p *Processor) handleHotkey(ev input.KeyEvent) bool {
+	if p.config.RecallHotkey == "" && len(p.config.Hotkeys) == 0 {
+		return false
+	}
+	combo := p.pressedCombo(ev.Name)
+	action, ok := p.config.Hotkeys[combo]
+	if !ok && combo != p.config.RecallHotkey {
+		return false
+	}
+
+	// The held modifiers belong to the hotkey, so they are neither taps
+	// for sticky modifiers nor keys to show on their own.
+	p.tapped = 0
+	p.removeEntries(p.modEntries)
+	p.modEntries = nil
+
+	if combo == p.config.RecallHotkey {
+		p.recall()
+		return true
+	}
+	if action == ActionClearHistory {
+		p.history = p.history[:0]
+		p.scrollback = p.scrollback[:0]
+		p.scheduleExpiry()
+		p.send(DisplayEvent{IsReset: true, Timestamp: time.Now()})
+	}
+	if p.onHotkey != nil {
+		go p.onHotkey(action)
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package processor
+
+// Glyph presets for key labels.
+const (
+	GlyphsText     = "text"
+	GlyphsSymbols  = "symbols"
+	GlyphsMacStyle = "mac-style"
+)
+
+var symbolGlyphs = map[string]string{
+	"Enter":     "⏎",
+	"NumEnter":  "⏎",
+	"Backspace": "⌫",
+	"Delete":    "⌦",
+	"Tab":       "⇥",
+	"Esc":       "⎋",
+	"Space":     "␣",
+	"CapsLock":  "⇪",
+	"Up":        "↑",
+	"Down":      "↓",
+	"Left":      "←",
+	"Right":     "→",
+	"Home":      "⇱",
+	"End":       "⇲",
+	"PageUp":    "⇞",
+	"PageDown":  "⇟",
+	"Super":     "❖",
+}
+
+var macModifierGlyphs = map[string]string{
+	"Ctrl":  "⌃",
+	"Alt":   "⌥",
+	"Shift": "⇧",
+	"Super": "⌘",
+}
+
+// newLabels layers translated key names, a glyph preset and per-key
+// overrides, each winning over the previous.
+func newLabels(names map[string]string, preset string, overrides map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for name, label := range names {
+		labels[name] = label
+	}
+	switch preset {
+	case GlyphsSymbols:
+		for name, glyph := range symbolGlyphs {
+			labels[name] = glyph
+		}
+	case GlyphsMacStyle:
+		for name, glyph := range symbolGlyphs {
+			labels[name] = glyph
+		}
+		for name, glyph := range macModifierGlyphs {
+			labels[name] = glyph
+		}
+	}
+	for name, label := range overrides {
+		labels[name] = label
+	}
+	return labels
+}
+
+// label returns the display text for a key or modifier name.
+func (p *Processor) label(name string) string {
+	if l, ok := p.labels[name]; ok {
+		return l
+	}
+	return name
+}
+
+func (p *Processor) labelParts(parts []string) []string {
+	labelled := make([]string, len(parts))
+	for i, part := range parts {
+		labelled[i] = p.label(part)
+	}
+	return labelled
+}
@@ -0,0 +1,274 @@
+package processor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tapshow/tapshow/internal/i18n"
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+// ModalEditor identifies the modal editor in the focused window, if any.
+type ModalEditor int
+
+const (
+	EditorNone ModalEditor = iota
+	EditorVim
+	EditorHelix
+)
+
+// Insert mode display options for modal editors.
+const (
+	InsertShow      = "show"
+	InsertHide      = "hide"
+	InsertAggregate = "aggregate"
+)
+
+type modalState struct {
+	editor      ModalEditor
+	insert      bool
+	cmdline     bool // typing a ":", "/" or "?" command line
+	pending     []rune
+	masks       []bool        // which pending keys are shown as MaskGlyph
+	lifetime    time.Duration // longest lifetime of the pending keys
+	typed       int
+	aggregateID uint64
+}
+
+// SetModalEditor switches modal editor handling on or off, e.g. when a
+// window running vim gains focus. Switching resets the tracked mode to
+// normal mode.
+func (p *Processor) SetModalEditor(editor ModalEditor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.modal.editor != editor {
+		p.modal = modalState{editor: editor}
+	}
+}
+
+// handleModalKey tracks the editor mode and groups normal-mode commands. It
+// reports whether the key was consumed and must not be shown on its own.
+// A masked key is shown as MaskGlyph within the grouped entry, which lives
+// as long as the longest lifetime of its keys.
+func (p *Processor) handleModalKey(ev input.KeyEvent, masked bool, lifetime time.Duration) bool {
+	m := &p.modal
+
+	if p.modifiers&shortcutMods != 0 {
+		if m.insert && p.modifiers&shortcutMods == input.ModCtrl &&
+			(ev.Code == input.KEY_LEFTBRACE || ev.Code == input.KEY_C) {
+			m.insert = false
+		}
+		p.flushModalCommand()
+		return false
+	}
+
+	if m.insert {
+		return p.handleInsertKey(ev, lifetime)
+	}
+
+	r, ok := input.KeyChar(ev.Code, p.modifiers&input.ModShift != 0)
+
+	if m.cmdline {
+		switch {
+		case ev.Code == input.KEY_ENTER:
+			p.flushModalCommand()
+			return true
+		case ev.Code == input.KEY_BACKSPACE:
+			if len(m.pending) > 1 {
+				m.pending = m.pending[:len(m.pending)-1]
+				m.masks = m.masks[:len(m.masks)-1]
+			}
+			return true
+		case ok:
+			m.push(r, masked, lifetime)
+			return true
+		}
+		p.flushModalCommand()
+		return false
+	}
+
+	if !ok {
+		p.flushModalCommand()
+		return false
+	}
+
+	m.push(r, masked, lifetime)
+
+	var complete, insert bool
+	if m.editor == EditorHelix {
+		complete, insert = helixCommand(m.pending)
+	} else {
+		complete, insert = vimCommand(m.pending)
+	}
+
+	switch {
+	case complete:
+		p.flushModalCommand()
+		if insert {
+			m.insert = true
+			m.typed = 0
+			m.aggregateID = 0
+		}
+	case isCmdlineStart(m.pending):
+		m.cmdline = true
+	}
+	return true
+}
+
+func (p *Processor) handleInsertKey(ev input.KeyEvent, lifetime time.Duration) bool {
+	m := &p.modal
+
+	if ev.Code == input.KEY_ESC {
+		m.insert = false
+		return false
+	}
+
+	if !isInsertTyping(ev.Code) {
+		return false
+	}
+
+	switch p.config.ModalInsertMode {
+	case InsertHide:
+		return true
+	case InsertAggregate:
+		m.typed++
+		text := i18n.T("insert_keys", m.typed)
+		if m.typed == 1 {
+			text = i18n.T("insert_key")
+		}
+		if m.aggregateID == 0 || !p.updateEntry(m.aggregateID, text) {
+			m.aggregateID = p.emitEvent(text, lifetime)
+		}
+		return true
+	}
+	return false
+}
+
+// flushModalCommand shows the pending normal-mode keys as a single entry.
+func (p *Processor) flushModalCommand() {
+	m := &p.modal
+	if len(m.pending) == 0 {
+		return
+	}
+
+	var text strings.Builder
+	for i, r := range m.pending {
+		switch {
+		case m.masks[i]:
+			text.WriteString(MaskGlyph)
+		case r == ' ':
+			text.WriteString("␣")
+		default:
+			text.WriteRune(r)
+		}
+	}
+	lifetime := m.lifetime
+	m.clearPending()
+	p.emitEvent(text.String(), lifetime)
+}
+
+func (m *modalState) push(r rune, masked bool, lifetime time.Duration) {
+	m.pending = append(m.pending, r)
+	m.masks = append(m.masks, masked)
+	m.lifetime = max(m.lifetime, lifetime)
+}
+
+// clearPending drops the keys of an unfinished command or command line.
+func (m *modalState) clearPending() {
+	m.pending = m.pending[:0]
+	m.masks = m.masks[:0]
+	m.lifetime = 0
+	m.cmdline = false
+}
+
+// isInsertTyping reports whether a key edits text while in insert mode.
+func isInsertTyping(code uint16) bool {
+	switch code {
+	case input.KEY_BACKSPACE, input.KEY_DELETE, input.KEY_ENTER, input.KEY_TAB:
+		return true
+	}
+	return input.IsPrintable(code)
+}
+
+// isCmdlineStart reports whether keys open a command line, optionally
+// preceded by a count as in "3:".
+func isCmdlineStart(keys []rune) bool {
+	last := keys[len(keys)-1]
+	if last != ':' && last != '/' && last != '?' {
+		return false
+	}
+	return skipCount(keys, 0) == len(keys)-1
+}
+
+// skipCount returns the index of the first key after an optional count.
+func skipCount(keys []rune, i int) int {
+	start := i
+	for i < len(keys) && keys[i] >= '0' && keys[i] <= '9' {
+		if i == start && keys[i] == '0' {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+// vimCommand reports whether keys form a complete vim normal-mode command,
+// e.g. "3dd" or "ciw", and whether it switches to insert mode.
+func vimCommand(keys []rune) (complete, insert bool) {
+	i := 0
+	if keys[0] == '"' {
+		i = 2
+	}
+	i = skipCount(keys, i)
+	if i >= len(keys) {
+		return false, false
+	}
+
+	cmd, rest := keys[i], keys[i+1:]
+	switch cmd {
+	case 'i', 'a', 'I', 'A', 'o', 'O', 's', 'S', 'C':
+		return true, true
+	case ':', '/', '?':
+		return false, false
+	case 'f', 'F', 't', 'T', 'r', 'm', '`', '\'', '@', 'g', 'z', 'Z', '[', ']':
+		return len(rest) >= 1, false
+	case 'd', 'c', 'y', '<', '>', '=':
+		j := skipCount(rest, 0)
+		if j >= len(rest) {
+			return false, false
+		}
+		switch rest[j] {
+		case 'i', 'a', 'f', 'F', 't', 'T', '`', '\'', 'g':
+			complete = len(rest) > j+1
+			return complete, complete && cmd == 'c'
+		}
+		return true, cmd == 'c'
+	}
+	return true, false
+}
+
+// helixCommand reports whether keys form a complete helix normal-mode
+// command and whether it switches to insert mode. Unlike vim, helix acts on
+// the current selection, so most commands are a single key.
+func helixCommand(keys []rune) (complete, insert bool) {
+	i := skipCount(keys, 0)
+	if i >= len(keys) {
+		return false, false
+	}
+
+	cmd, rest := keys[i], keys[i+1:]
+	switch cmd {
+	case 'i', 'a', 'I', 'A', 'o', 'O', 'c':
+		return true, true
+	case ':', '/', '?':
+		return false, false
+	case 'f', 'F', 't', 'T', 'r', 'g', 'z', 'Z', '[', ']', ' ':
+		return len(rest) >= 1, false
+	case 'm':
+		if len(rest) >= 1 && rest[0] == 'm' {
+			return true, false
+		}
+		return len(rest) >= 2, false
+	}
+	return true, false
+}
@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+// Notation styles for shortcuts.
+const (
+	NotationDefault = "default" // Ctrl+Shift+X
+	NotationEmacs   = "emacs"   // C-S-x
+	NotationVim     = "vim"     // <C-S-x>
+	NotationMac     = "mac"     // ⌃⇧X
+	NotationKeycaps = "keycaps" // Ctrl Shift X, one keycap each
+)
+
+var emacsModifiers = map[string]string{"Ctrl": "C", "Alt": "M", "Shift": "S", "Super": "s"}
+
+var emacsKeys = map[string]string{
+	"Enter":     "RET",
+	"Tab":       "TAB",
+	"Space":     "SPC",
+	"Backspace": "DEL",
+	"Esc":       "ESC",
+}
+
+var vimModifiers = map[string]string{"Ctrl": "C", "Alt": "M", "Shift": "S", "Super": "D"}
+
+var vimKeys = map[string]string{
+	"Enter":     "CR",
+	"Backspace": "BS",
+	"Delete":    "Del",
+	"Esc":       "Esc",
+}
+
+// formatKeys renders modifiers and keys in the configured notation. It also
+// returns the labelled parts as separate keycaps for chords and the keycaps
+// notation, and nil otherwise.
+func (p *Processor) formatKeys(mods input.Modifier, keyNames ...string) (string, []string) {
+	if !p.config.CombineModifiers {
+		mods = 0
+	}
+	modNames := p.orderedModifiers(mods)
+
+	var caps []string
+	if len(keyNames) > 1 || p.config.Notation == NotationKeycaps {
+		caps = p.labelParts(append(modNames, keyNames...))
+	}
+
+	var parts []string
+	switch p.config.Notation {
+	case NotationEmacs:
+		for _, m := range modNames {
+			parts = append(parts, emacsModifiers[m])
+		}
+		for _, k := range keyNames {
+			parts = append(parts, emacsKey(k))
+		}
+		return strings.Join(parts, p.separator("-")), caps
+
+	case NotationVim:
+		for _, m := range modNames {
+			parts = append(parts, vimModifiers[m])
+		}
+		for _, k := range keyNames {
+			parts = append(parts, vimKey(k))
+		}
+		text := strings.Join(parts, p.separator("-"))
+		if len(parts) > 1 || utf8.RuneCountInString(text) > 1 {
+			text = "<" + text + ">"
+		}
+		return text, caps
+
+	case NotationMac:
+		for _, m := range modNames {
+			parts = append(parts, macModifierGlyphs[m])
+		}
+		for _, k := range keyNames {
+			label, ok := p.config.Labels[k]
+			if !ok {
+				if label, ok = symbolGlyphs[k]; !ok {
+					label = p.label(k)
+				}
+			}
+			parts = append(parts, label)
+		}
+		return strings.Join(parts, p.separator("")), caps
+
+	case NotationKeycaps:
+		return strings.Join(caps, p.separator(" ")), caps
+	}
+
+	parts = p.labelParts(append(modNames, keyNames...))
+	return strings.Join(parts, p.separator("+")), caps
+}
+
+func (p *Processor) separator(style string) string {
+	if p.config.Separator != "" {
+		return p.config.Separator
+	}
+	return style
+}
+
+// orderedModifiers returns the modifier names in Config.ModifierOrder, with
+// any modifiers not listed there following in the default order.
+func (p *Processor) orderedModifiers(mods input.Modifier) []string {
+	names := modifierNames(mods)
+	if len(p.config.ModifierOrder) == 0 {
+		return names
+	}
+	rank := func(name string) int {
+		for i, m := range p.config.ModifierOrder {
+			if strings.EqualFold(strings.TrimSpace(m), name) {
+				return i
+			}
+		}
+		return len(p.config.ModifierOrder)
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return rank(names[i]) < rank(names[j])
+	})
+	return names
+}
+
+func emacsKey(name string) string {
+	if k, ok := emacsKeys[name]; ok {
+		return k
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		return strings.ToLower(name)
+	}
+	return "<" + strings.ToLower(name) + ">"
+}
+
+func vimKey(name string) string {
+	if k, ok := vimKeys[name]; ok {
+		return k
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		return strings.ToLower(name)
+	}
+	return name
+}
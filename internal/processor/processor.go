@@ -1,72 +1,288 @@
 package processor
 
 import (
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/tapshow/tapshow/internal/compose"
+	"github.com/tapshow/tapshow/internal/i18n"
 	"github.com/tapshow/tapshow/internal/input"
 )
 
 type DisplayEvent struct {
+	ID        uint64 // identifies a history entry across in-place updates
 	Text      string
+	Combo     string   // canonical key combo behind Text, e.g. "Ctrl+Shift+P"; empty if masked
+	Keys      []string // individual keycaps when the event is a chord
 	Timestamp time.Time
 	IsHeld    bool
+	IsArmed   bool // sticky modifiers waiting for the next key
 	IsReset   bool
+	IsDivider bool // starts a new segment after a focus change; Text is the app
+	IsFading  bool // the entry is about to expire
+	IsExpired bool // entries were removed from the history
+	IsRecall  bool // shows Recall; an empty Recall hides it again
+	IsStats   bool // carries KPM and WPM
+	IsModBar  bool // carries the physically held Modifiers
+	IsHint    bool // a coaching hint; an empty Text hides it
+
+	Recall    []DisplayEvent // recent scrollback entries, oldest first
+	KPM       int            // keys per minute over Config.RateWindow
+	WPM       int
+	Modifiers []string // e.g. "Ctrl", "Shift"
+
+	// History is a snapshot of the history as of this event, so consumers
+	// don't need to call Processor.History separately.
+	History []DisplayEvent
+
+	lifetime time.Duration
+	expires  time.Time
+}
+
+// IsStatus reports whether the event reports transient state rather than a
+// key press that belongs in the history.
+func (e DisplayEvent) IsStatus() bool {
+	return e.IsHeld || e.IsArmed || e.IsRecall || e.IsStats || e.IsModBar || e.IsHint
 }
 
 type Processor struct {
-	events     chan DisplayEvent
-	done       chan struct{}
-	config     Config
-	mu         sync.Mutex
-	modifiers  input.Modifier
-	history    []DisplayEvent
-	lastKey    *input.KeyEvent
-	heldTimer  *time.Timer
-	resetTimer *time.Timer
+	subs        []*Subscription
+	defaultSub  *Subscription
+	done        chan struct{}
+	config      Config
+	excluded    classFilter
+	deadKeys    map[rune]bool
+	mu          sync.Mutex
+	modifiers   input.Modifier
+	history     []DisplayEvent
+	nextID      uint64
+	held        []*heldKey
+	chord       *pendingChord
+	masked      bool
+	modal       modalState
+	tapped      input.Modifier
+	modEntries  []uint64 // ShowModifierOnly entries of the modifiers held now
+	sticky      *stickyMods
+	sequence    *sequence
+	numLock     bool
+	focusApp    string
+	holdTimer   *time.Timer
+	expiryTimer *time.Timer
+	scrollback  []DisplayEvent
+	recallTimer *time.Timer
+	paused      bool
+	rate        rateCounter
+	coaching    coachState
+	recent      []string // recent key combos for coaching
+	secret      secretRun
+	repeatCombo string
+	repeatCount int
+	labels      map[string]string
+	onHotkey    func(action string)
+}
+
+// heldKey tracks a single pressed key and its own hold timer.
+type heldKey struct {
+	code    uint16
+	text    string
+	entryID uint64 // history entry of the press, if it got one of its own
+	start   time.Time
+	timer   *time.Timer
+	held    bool
+}
+
+// pendingChord collects keys pressed within ChordWindow of each other so
+// they can be shown as a single entry.
+type pendingChord struct {
+	mods     input.Modifier
+	keys     []string
+	start    time.Time
+	timer    *time.Timer
+	secret   bool          // holds keys of the current secret run
+	lifetime time.Duration // longest lifetime of its keys
 }
 
 type Config struct {
-	CombineModifiers bool
-	ShowModifierOnly bool
-	ShowHeldKeys     bool
-	HeldKeyTimeout   time.Duration
-	ResetTimeout     time.Duration
-	HistoryCount     int
-	ExcludedKeys     []string
+	CombineModifiers   bool
+	ShowModifierOnly   bool
+	ShowHeldKeys       bool
+	HeldKeyTimeout     time.Duration
+	ResetTimeout       time.Duration // default lifetime of history entries
+	ShortcutLifetime   time.Duration // zero uses ResetTimeout
+	SpecialLifetime    time.Duration
+	PrintableLifetime  time.Duration
+	HistoryCount       int
+	ExcludedKeys       []string
+	DetectChords       bool
+	ChordWindow        time.Duration
+	ExcludedClasses    []string
+	ShortcutsOnly      bool
+	MaskPrintable      bool
+	ModalInsertMode    string // show, hide, aggregate
+	StickyModifiers    bool
+	StickyTimeout      time.Duration
+	ComposeSequences   bool
+	DeadKeys           []string       // characters acting as dead keys, e.g. "'"
+	ComposeTable       *compose.Table // resolves compose and dead key sequences
+	NumLock            bool           // NumLock state when the processor starts
+	KeypadAsDigits     bool
+	ResetOnFocusChange bool
+	FocusDivider       string // none, line, label
+	ShowHoldDuration   bool
+	ScrollbackCount    int
+	RecallHotkey       string // e.g. "Super+Shift+H"; empty disables recall
+	RecallCount        int
+	RecallDuration     time.Duration
+	Hotkeys            map[string]string // combo to action, e.g. "Super+F9": ActionToggleOverlay
+	ShowRate           bool
+	RateWindow         time.Duration
+	ShowModifierBar    bool
+	Coaching           bool
+	CoachRules         []CoachRule
+	CoachCooldown      time.Duration // per rule
+	HintDuration       time.Duration
+	RetractSecrets     bool
+	SecretRules        SecretRules
+	Rules              []Rule
+	Glyphs             string            // text, symbols, mac-style
+	Labels             map[string]string // key or modifier name to label, e.g. "Enter": "⏎"
+	KeyNames           map[string]string // translated key names, below Glyphs and Labels
+	Notation           string            // default, emacs, vim, mac, keycaps
+	ModifierOrder      []string          // e.g. Super, Ctrl, Alt, Shift; empty keeps Ctrl, Alt, Shift, Super
+	Separator          string            // joins the parts; empty uses the notation's own
+}
+
+// MaskGlyph replaces printable keys while the masked display mode is active.
+const MaskGlyph = "•"
+
+// UnmodifiedPrintable is a pseudo key class matching printable keys pressed
+// without Ctrl, Alt or Super, i.e. ordinary typing.
+const UnmodifiedPrintable = "unmodified_printable"
+
+type classFilter struct {
+	classes             map[input.KeyClass]bool
+	unmodifiedPrintable bool
 }
 
+// shortcutMods are the modifiers that turn a key press into a shortcut.
+const shortcutMods = input.ModCtrl | input.ModAlt | input.ModSuper
+
 func DefaultConfig() Config {
 	return Config{
-		CombineModifiers: true,
-		ShowModifierOnly: false,
-		ShowHeldKeys:     true,
-		HeldKeyTimeout:   500 * time.Millisecond,
-		ResetTimeout:     2000 * time.Millisecond,
-		HistoryCount:     4,
-		ExcludedKeys:     []string{},
+		CombineModifiers:   true,
+		ShowModifierOnly:   false,
+		ShowHeldKeys:       true,
+		HeldKeyTimeout:     500 * time.Millisecond,
+		ResetTimeout:       2000 * time.Millisecond,
+		HistoryCount:       4,
+		ExcludedKeys:       []string{},
+		DetectChords:       false,
+		ChordWindow:        30 * time.Millisecond,
+		ExcludedClasses:    []string{},
+		ShortcutsOnly:      false,
+		MaskPrintable:      false,
+		ModalInsertMode:    InsertAggregate,
+		StickyModifiers:    false,
+		StickyTimeout:      1000 * time.Millisecond,
+		ComposeSequences:   false,
+		DeadKeys:           []string{},
+		NumLock:            true,
+		KeypadAsDigits:     false,
+		ResetOnFocusChange: false,
+		FocusDivider:       DividerNone,
+		ShowHoldDuration:   false,
+		ScrollbackCount:    50,
+		RecallHotkey:       "",
+		RecallCount:        10,
+		RecallDuration:     5 * time.Second,
+		ShowRate:           false,
+		RateWindow:         10 * time.Second,
+		ShowModifierBar:    false,
+		Coaching:           false,
+		CoachCooldown:      time.Minute,
+		HintDuration:       4 * time.Second,
+		RetractSecrets:     false,
+		SecretRules:        SecretRules{MinLength: 8, MinEntropyBits: 24, MinClasses: 3},
+		Glyphs:             GlyphsText,
+		Notation:           NotationDefault,
 	}
 }
 
 func New(cfg Config) *Processor {
+	p := &Processor{
+		done:    make(chan struct{}),
+		numLock: cfg.NumLock,
+	}
+	p.configure(cfg)
+	p.history = make([]DisplayEvent, 0, p.config.HistoryCount)
+	p.defaultSub = p.Subscribe("default", 50, DropNewest)
+	return p
+}
+
+// SetConfig switches to cfg, e.g. when cycling profiles. The history, held
+// keys and NumLock state are kept; a pending chord, sequence or modal
+// command is dropped.
+func (p *Processor) SetConfig(cfg Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dropPending()
+	p.configure(cfg)
+}
+
+// configure normalizes cfg and builds the lookups derived from it.
+func (p *Processor) configure(cfg Config) {
 	normalizedExcluded := make([]string, len(cfg.ExcludedKeys))
 	for i, key := range cfg.ExcludedKeys {
 		normalizedExcluded[i] = normalizeKeyCombo(key)
 	}
 	cfg.ExcludedKeys = normalizedExcluded
+	if cfg.RecallHotkey != "" {
+		cfg.RecallHotkey = normalizeKeyCombo(cfg.RecallHotkey)
+	}
+	hotkeys := make(map[string]string, len(cfg.Hotkeys))
+	for combo, action := range cfg.Hotkeys {
+		if combo != "" {
+			hotkeys[normalizeKeyCombo(combo)] = action
+		}
+	}
+	cfg.Hotkeys = hotkeys
+	cfg.Rules = normalizeRules(cfg.Rules)
+	if cfg.RateWindow <= 0 {
+		cfg.RateWindow = DefaultConfig().RateWindow
+	}
 
-	return &Processor{
-		events:  make(chan DisplayEvent, 50),
-		done:    make(chan struct{}),
-		config:  cfg,
-		history: make([]DisplayEvent, 0, cfg.HistoryCount),
+	excluded := classFilter{classes: make(map[input.KeyClass]bool)}
+	for _, name := range cfg.ExcludedClasses {
+		if strings.EqualFold(strings.TrimSpace(name), UnmodifiedPrintable) {
+			excluded.unmodifiedPrintable = true
+		} else if class, ok := input.ParseKeyClass(name); ok {
+			excluded.classes[class] = true
+		}
 	}
+
+	deadKeys := make(map[rune]bool)
+	for _, key := range cfg.DeadKeys {
+		if r := []rune(key); len(r) == 1 {
+			deadKeys[r[0]] = true
+		}
+	}
+
+	p.config = cfg
+	p.excluded = excluded
+	p.deadKeys = deadKeys
+	p.labels = newLabels(cfg.KeyNames, cfg.Glyphs, cfg.Labels)
+	hintTimer := p.coaching.hintTimer
+	p.coaching = newCoachState(cfg.CoachRules)
+	p.coaching.hintTimer = hintTimer
 }
 
+// Events returns the default stream, which drops new events when full. Use
+// Subscribe for a stream with its own buffer and overflow policy.
 func (p *Processor) Events() <-chan DisplayEvent {
-	return p.events
+	return p.defaultSub.Events()
 }
 
 func (p *Processor) History() []DisplayEvent {
@@ -83,6 +299,14 @@ func (p *Processor) ClearHistory() {
 	p.history = p.history[:0]
 }
 
+// SetMasked toggles the masked display mode on top of Config.MaskPrintable,
+// e.g. while an app matched by the privacy monitor is focused.
+func (p *Processor) SetMasked(masked bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.masked = masked
+}
+
 func (p *Processor) Process(inputEvents <-chan input.KeyEvent) {
 	for {
 		select {
@@ -99,11 +323,35 @@ func (p *Processor) Process(inputEvents <-chan input.KeyEvent) {
 
 func (p *Processor) Stop() {
 	close(p.done)
-	if p.heldTimer != nil {
-		p.heldTimer.Stop()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, hk := range p.held {
+		hk.timer.Stop()
+	}
+	p.held = nil
+	if p.holdTimer != nil {
+		p.holdTimer.Stop()
+		p.holdTimer = nil
+	}
+	if p.sticky != nil {
+		p.sticky.timer.Stop()
+		p.sticky = nil
+	}
+	if p.chord != nil {
+		p.chord.timer.Stop()
+		p.chord = nil
 	}
-	if p.resetTimer != nil {
-		p.resetTimer.Stop()
+	if p.expiryTimer != nil {
+		p.expiryTimer.Stop()
+	}
+	if p.recallTimer != nil {
+		p.recallTimer.Stop()
+	}
+	if p.rate.timer != nil {
+		p.rate.timer.Stop()
+	}
+	if p.coaching.hintTimer != nil {
+		p.coaching.hintTimer.Stop()
 	}
 }
 
@@ -113,67 +361,309 @@ func (p *Processor) handleKeyEvent(ev input.KeyEvent) {
 
 	if input.IsModifier(ev.Code) {
 		mod := input.GetModifier(ev.Code)
+		before := p.modifiers
 		if ev.State == input.KeyPressed {
 			p.modifiers |= mod
 		} else if ev.State == input.KeyReleased {
 			p.modifiers &^= mod
 		}
+		if p.modifiers == 0 {
+			p.modEntries = nil
+		}
+		if p.config.ShowModifierBar && p.modifiers != before {
+			p.publish(DisplayEvent{
+				IsModBar:  true,
+				Modifiers: modifierNames(p.modifiers),
+				Timestamp: time.Now(),
+			})
+		}
+		if p.paused {
+			return
+		}
 
 		if p.config.ShowModifierOnly && ev.State == input.KeyPressed {
 			if !p.isExcluded(ev.Name) {
-				p.emitEvent(ev.Name, false)
+				id := p.publish(DisplayEvent{Text: p.label(ev.Name), Combo: ev.Name, Timestamp: time.Now(), lifetime: p.lifetime(kindSpecial)})
+				p.modEntries = append(p.modEntries, id)
 			}
 		}
+		if p.config.StickyModifiers {
+			p.trackModifierTap(mod, ev.State)
+		}
+		if ev.State == input.KeyReleased {
+			p.sequenceModifierReleased()
+		}
 		return
 	}
 
+	if ev.Code == input.KEY_NUMLOCK && ev.State == input.KeyPressed {
+		p.numLock = !p.numLock
+	}
+	// Held keys are tracked by the physical key, since NumLock may toggle
+	// while a keypad key is down and change what it translates to.
+	physical := ev.Code
+	ev = p.translateKeypad(ev)
+
 	switch ev.State {
 	case input.KeyPressed:
-		text := p.buildKeyText(ev.Name)
-		if p.isExcluded(text) {
+		if p.handleHotkey(ev) || p.paused {
 			return
 		}
-		p.emitEvent(text, false)
-		p.lastKey = &ev
 
-		if p.config.ShowHeldKeys {
-			if p.heldTimer != nil {
-				p.heldTimer.Stop()
+		p.tapped = 0
+		p.modEntries = nil
+		if sticky := p.takeSticky(); sticky != 0 {
+			// Apply the armed modifiers to this key press only.
+			physical := p.modifiers
+			p.modifiers |= sticky
+			defer func() { p.modifiers = physical }()
+		}
+		kind := p.keyKind(ev.Code)
+		p.coach(ev.Name)
+
+		if p.config.ComposeSequences && !p.maskActive() && p.handleSequenceKey(ev) {
+			return
+		}
+
+		combo := p.keyCombo(ev.Name)
+		text, caps := p.buildKeyText(ev.Name)
+		if p.isExcluded(combo) || p.isExcludedClass(ev.Code) {
+			p.trackSecret(ev, 0)
+			return
+		}
+		p.countKey(ev.Code)
+		masked := p.isMasked(ev.Code)
+		if masked {
+			text, combo = MaskGlyph, ""
+		}
+		res := p.applyRules(ev, text, masked)
+		if res.hidden {
+			p.trackSecret(ev, 0)
+			return
+		}
+		relabelled := res.text != text
+		if masked || relabelled {
+			caps = nil
+		}
+		text, masked = res.text, res.masked
+		if masked {
+			combo = ""
+		}
+		lifetime := p.lifetime(kind)
+		if res.lifetime > 0 {
+			lifetime = res.lifetime
+		}
+
+		var entryID uint64
+		switch {
+		case p.modal.editor != EditorNone && p.handleModalKey(ev, masked, lifetime):
+		case p.config.DetectChords && !masked && !relabelled:
+			p.addToChord(ev, lifetime)
+		default:
+			// Keys a rule relabelled are shown on their own, after any
+			// pending chord.
+			if p.chord != nil {
+				p.flushChord()
 			}
-			p.heldTimer = time.AfterFunc(p.config.HeldKeyTimeout, func() {
-				p.mu.Lock()
-				defer p.mu.Unlock()
-				if p.lastKey != nil && p.lastKey.Code == ev.Code {
-					text := p.buildKeyText(ev.Name)
-					if !p.isExcluded(text) {
-						p.emitEvent(text+" (held)", true)
-					}
-				}
-			})
+			entryID = p.publish(DisplayEvent{Text: text, Combo: combo, Keys: caps, Timestamp: time.Now(), lifetime: lifetime})
 		}
+		p.trackSecret(ev, entryID)
 
-	case input.KeyReleased:
-		if p.heldTimer != nil {
-			p.heldTimer.Stop()
+		if p.config.ShowHeldKeys {
+			p.trackHeld(physical, text, entryID)
 		}
-		p.lastKey = nil
+
+	case input.KeyReleased:
+		p.releaseHeld(physical)
 
 	case input.KeyHeld:
 		if p.config.ShowHeldKeys {
-			text := p.buildKeyText(ev.Name)
-			if !p.isExcluded(text) {
-				p.emitEvent(text, true)
+			if hk := p.findHeld(physical); hk != nil {
+				p.markHeld(hk)
 			}
 		}
 	}
 }
 
-func (p *Processor) buildKeyText(keyName string) string {
-	if !p.config.CombineModifiers || p.modifiers == 0 {
-		return keyName
+// translateKeypad names keypad keys by what they do: with NumLock off they
+// act as navigation keys, with it on they type digits.
+func (p *Processor) translateKeypad(ev input.KeyEvent) input.KeyEvent {
+	if !p.numLock {
+		if nav, ok := input.KeypadNavigation(ev.Code); ok {
+			ev.Code = nav
+			ev.Name = input.GetKeyName(nav)
+		}
+		return ev
 	}
 
+	if p.config.KeypadAsDigits && input.IsKeypadDigit(ev.Code) {
+		if r, ok := input.KeyChar(ev.Code, false); ok {
+			ev.Name = string(r)
+		}
+	}
+	return ev
+}
+
+func (p *Processor) findHeld(code uint16) *heldKey {
+	for _, hk := range p.held {
+		if hk.code == code {
+			return hk
+		}
+	}
+	return nil
+}
+
+func (p *Processor) trackHeld(code uint16, text string, entryID uint64) {
+	p.releaseHeld(code)
+
+	hk := &heldKey{code: code, text: text, entryID: entryID, start: time.Now()}
+	hk.timer = time.AfterFunc(p.config.HeldKeyTimeout, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		// The key may have been released or pressed again since the timer
+		// was armed; only the entry that created this timer may mark itself.
+		if p.findHeld(code) == hk {
+			p.markHeld(hk)
+		}
+	})
+	p.held = append(p.held, hk)
+}
+
+func (p *Processor) markHeld(hk *heldKey) {
+	if hk.held {
+		return
+	}
+	hk.timer.Stop()
+	hk.held = true
+	p.emitHeld()
+	if p.config.ShowHoldDuration {
+		p.scheduleHoldTick()
+	}
+}
+
+func (p *Processor) releaseHeld(code uint16) {
+	for i, hk := range p.held {
+		if hk.code != code {
+			continue
+		}
+		hk.timer.Stop()
+		p.held = append(p.held[:i], p.held[i+1:]...)
+		if hk.held {
+			p.emitHeld()
+			if p.config.ShowHoldDuration && hk.entryID != 0 {
+				p.updateEntry(hk.entryID, hk.text+" "+formatHold(time.Since(hk.start)))
+				return
+			}
+		}
+		p.touchEntry(hk.entryID)
+		return
+	}
+}
+
+// emitHeld publishes every key currently in the held state as a single
+// event. An empty text tells the display that no keys are held anymore.
+func (p *Processor) emitHeld() {
 	var parts []string
+	for _, hk := range p.held {
+		switch {
+		case !hk.held:
+		case p.config.ShowHoldDuration:
+			parts = append(parts, hk.text+" "+formatHold(time.Since(hk.start)))
+		default:
+			parts = append(parts, hk.text)
+		}
+	}
+
+	text := ""
+	switch {
+	case len(parts) == 0:
+	case p.config.ShowHoldDuration:
+		text = strings.Join(parts, " + ")
+	default:
+		text = strings.Join(parts, " + ") + " " + i18n.T("held")
+	}
+	p.publish(DisplayEvent{Text: text, IsHeld: true, Timestamp: time.Now()})
+}
+
+// addToChord buffers a key press. Presses whose timestamps fall within
+// ChordWindow of the first one, including those from the same SYN frame,
+// are flushed together once the window has passed.
+func (p *Processor) addToChord(ev input.KeyEvent, lifetime time.Duration) {
+	if p.chord != nil && ev.Timestamp.Sub(p.chord.start) > p.config.ChordWindow {
+		p.flushChord()
+	}
+
+	if p.chord == nil {
+		c := &pendingChord{mods: p.modifiers, start: ev.Timestamp}
+		c.timer = time.AfterFunc(p.config.ChordWindow, func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.chord == c {
+				p.flushChord()
+			}
+		})
+		p.chord = c
+	}
+
+	p.chord.keys = append(p.chord.keys, ev.Name)
+	p.chord.lifetime = max(p.chord.lifetime, lifetime)
+}
+
+func (p *Processor) flushChord() {
+	c := p.chord
+	p.chord = nil
+	c.timer.Stop()
+
+	combo := strings.Join(p.buildKeyParts(c.mods, c.keys...), "+")
+	text, caps := p.formatKeys(c.mods, c.keys...)
+	if len(c.keys) > 1 && p.isExcluded(combo) {
+		return
+	}
+	id := p.publish(DisplayEvent{
+		Text:      text,
+		Combo:     combo,
+		Keys:      caps,
+		Timestamp: time.Now(),
+		lifetime:  c.lifetime,
+	})
+	if c.secret && len(p.secret.chars) > 0 {
+		p.secret.ids = append(p.secret.ids, id)
+	}
+}
+
+// isMasked reports whether a key pressed with the current modifiers should be
+// shown as MaskGlyph. Shortcuts and non-printable keys are always shown.
+func (p *Processor) isMasked(code uint16) bool {
+	return p.maskActive() && input.IsPrintable(code) && p.modifiers&shortcutMods == 0
+}
+
+func (p *Processor) maskActive() bool {
+	return p.config.MaskPrintable || p.masked
+}
+
+// buildKeyText returns the labelled text shown for a key pressed with the
+// current modifiers, and its keycaps in the keycaps notation.
+func (p *Processor) buildKeyText(keyName string) (string, []string) {
+	return p.formatKeys(p.modifiers, keyName)
+}
+
+// keyCombo returns the canonical, unlabelled form of buildKeyText, which is
+// what excluded keys are matched against.
+func (p *Processor) keyCombo(keyName string) string {
+	return strings.Join(p.buildKeyParts(p.modifiers, keyName), "+")
+}
+
+// buildKeyParts returns the active modifier names followed by the key names.
+func (p *Processor) buildKeyParts(mods input.Modifier, keyNames ...string) []string {
+	if !p.config.CombineModifiers || mods == 0 {
+		return keyNames
+	}
+
+	return append(modifierNames(mods), keyNames...)
+}
+
+func modifierNames(mods input.Modifier) []string {
+	var names []string
 	for _, m := range []struct {
 		mod  input.Modifier
 		name string
@@ -183,52 +673,77 @@ func (p *Processor) buildKeyText(keyName string) string {
 		{input.ModShift, "Shift"},
 		{input.ModSuper, "Super"},
 	} {
-		if p.modifiers&m.mod != 0 {
-			parts = append(parts, m.name)
+		if mods&m.mod != 0 {
+			names = append(names, m.name)
 		}
 	}
-
-	parts = append(parts, keyName)
-	return strings.Join(parts, "+")
+	return names
 }
 
-func (p *Processor) emitEvent(text string, isHeld bool) {
-	event := DisplayEvent{
+func (p *Processor) emitEvent(text string, lifetime time.Duration) uint64 {
+	return p.publish(DisplayEvent{
 		Text:      text,
 		Timestamp: time.Now(),
-		IsHeld:    isHeld,
-	}
+		lifetime:  lifetime,
+	})
+}
 
-	if !isHeld {
+// publish sends an event to the display, recording it in the history unless
+// it only reports transient state. History entries keep event.lifetime, so
+// callers set it, usually from Processor.lifetime. It returns the ID of the
+// new history entry.
+func (p *Processor) publish(event DisplayEvent) uint64 {
+	if !event.IsStatus() {
+		p.nextID++
+		event.ID = p.nextID
+		event.touch(time.Now())
 		if len(p.history) >= p.config.HistoryCount {
 			p.history = p.history[1:]
 		}
 		p.history = append(p.history, event)
+		p.recordScrollback(event)
+		p.scheduleExpiry()
 	}
 
-	p.scheduleReset()
+	p.send(event)
+	return event.ID
+}
 
-	select {
-	case p.events <- event:
-	default:
+// updateEntry replaces the text of a history entry in place. It reports
+// false if the entry is no longer part of the history.
+func (p *Processor) updateEntry(id uint64, text string) bool {
+	for i := range p.history {
+		if p.history[i].ID == id {
+			p.history[i].Text = text
+			p.history[i].touch(time.Now())
+			p.recordScrollback(p.history[i])
+			p.scheduleExpiry()
+			p.send(p.history[i])
+			return true
+		}
 	}
+	return false
 }
 
-func (p *Processor) scheduleReset() {
-	if p.resetTimer != nil {
-		p.resetTimer.Stop()
+// removeEntries drops the entries with the given IDs from the history and
+// scrollback.
+func (p *Processor) removeEntries(ids []uint64) {
+	if len(ids) == 0 {
+		return
 	}
-	if p.config.ResetTimeout > 0 {
-		p.resetTimer = time.AfterFunc(p.config.ResetTimeout, func() {
-			p.mu.Lock()
-			p.history = p.history[:0]
-			p.mu.Unlock()
+	p.history = slices.DeleteFunc(p.history, func(e DisplayEvent) bool { return slices.Contains(ids, e.ID) })
+	p.scrollback = slices.DeleteFunc(p.scrollback, func(e DisplayEvent) bool { return slices.Contains(ids, e.ID) })
+	p.scheduleExpiry()
+	p.send(DisplayEvent{IsExpired: true, Timestamp: time.Now()})
+}
 
-			select {
-			case p.events <- DisplayEvent{IsReset: true, Timestamp: time.Now()}:
-			default:
-			}
-		})
+// send delivers an event with a snapshot of the history to every
+// subscriber.
+func (p *Processor) send(event DisplayEvent) {
+	event.History = make([]DisplayEvent, len(p.history))
+	copy(event.History, p.history)
+	for _, sub := range p.subs {
+		sub.deliver(event, p.done)
 	}
 }
 
@@ -256,3 +771,18 @@ func (p *Processor) isExcluded(text string) bool {
 	}
 	return false
 }
+
+// isExcludedClass applies the class based exclusion rules and shortcuts-only
+// mode to a key pressed with the current modifiers.
+func (p *Processor) isExcludedClass(code uint16) bool {
+	isShortcut := p.modifiers&shortcutMods != 0
+	printable := input.IsPrintable(code)
+
+	if p.config.ShortcutsOnly && !isShortcut && printable {
+		return true
+	}
+	if p.excluded.unmodifiedPrintable && !isShortcut && printable {
+		return true
+	}
+	return p.excluded.classes[input.GetKeyClass(code)]
+}
@@ -1,9 +1,14 @@
 package processor
 
 import (
+	"strings"
+	"sync"
 	"testing"
+	"testing/synctest"
 	"time"
 
+	"github.com/tapshow/tapshow/internal/compose"
+	"github.com/tapshow/tapshow/internal/i18n"
 	"github.com/tapshow/tapshow/internal/input"
 )
 
@@ -322,3 +327,1489 @@ func TestNormalizeKeyCombo(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessor_MultipleHeldKeys(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HeldKeyTimeout = 20 * time.Millisecond
+
+	proc := New(cfg)
+	events := make(chan input.KeyEvent, 10)
+
+	go proc.Process(events)
+	defer proc.Stop()
+
+	events <- input.KeyEvent{Code: input.KEY_W, Name: "W", State: input.KeyPressed}
+	time.Sleep(40 * time.Millisecond)
+	events <- input.KeyEvent{Code: input.KEY_A, Name: "A", State: input.KeyPressed}
+	time.Sleep(40 * time.Millisecond)
+
+	var held []string
+	for len(proc.Events()) > 0 {
+		if event := <-proc.Events(); event.IsHeld {
+			held = append(held, event.Text)
+		}
+	}
+
+	expected := []string{"W (held)", "W + A (held)"}
+	if len(held) != len(expected) {
+		t.Fatalf("Held events = %q, want %q", held, expected)
+	}
+	for i := range expected {
+		if held[i] != expected[i] {
+			t.Errorf("Held[%d] = %q, want %q", i, held[i], expected[i])
+		}
+	}
+
+	events <- input.KeyEvent{Code: input.KEY_W, Name: "W", State: input.KeyReleased}
+	time.Sleep(20 * time.Millisecond)
+
+	select {
+	case event := <-proc.Events():
+		if !event.IsHeld || event.Text != "A (held)" {
+			t.Errorf("Expected 'A (held)' after releasing W, got %q", event.Text)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("Timeout waiting for held update")
+	}
+
+	events <- input.KeyEvent{Code: input.KEY_A, Name: "A", State: input.KeyReleased}
+	time.Sleep(20 * time.Millisecond)
+
+	select {
+	case event := <-proc.Events():
+		if !event.IsHeld || event.Text != "" {
+			t.Errorf("Expected empty held event after releasing all keys, got %q", event.Text)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("Timeout waiting for held clear")
+	}
+}
+
+func TestProcessor_ReleaseBeforeHeldTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HeldKeyTimeout = 30 * time.Millisecond
+
+	proc := New(cfg)
+	events := make(chan input.KeyEvent, 10)
+
+	go proc.Process(events)
+	defer proc.Stop()
+
+	events <- input.KeyEvent{Code: input.KEY_W, Name: "W", State: input.KeyPressed}
+	events <- input.KeyEvent{Code: input.KEY_W, Name: "W", State: input.KeyReleased}
+	time.Sleep(60 * time.Millisecond)
+
+	for len(proc.Events()) > 0 {
+		if event := <-proc.Events(); event.IsHeld {
+			t.Errorf("Released key should not be shown as held, got %q", event.Text)
+		}
+	}
+}
+
+func TestProcessor_Chord(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.DetectChords = true
+	cfg.ChordWindow = 20 * time.Millisecond
+
+	proc := New(cfg)
+	events := make(chan input.KeyEvent, 10)
+
+	go proc.Process(events)
+	defer proc.Stop()
+
+	now := time.Now()
+	events <- input.KeyEvent{Code: input.KEY_J, Name: "J", State: input.KeyPressed, Timestamp: now}
+	events <- input.KeyEvent{Code: input.KEY_K, Name: "K", State: input.KeyPressed, Timestamp: now.Add(5 * time.Millisecond)}
+	events <- input.KeyEvent{Code: input.KEY_L, Name: "L", State: input.KeyPressed, Timestamp: now.Add(50 * time.Millisecond)}
+
+	time.Sleep(60 * time.Millisecond)
+
+	var got []DisplayEvent
+	for len(proc.Events()) > 0 {
+		got = append(got, <-proc.Events())
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 events, got %d: %+v", len(got), got)
+	}
+	if got[0].Text != "J+K" || len(got[0].Keys) != 2 {
+		t.Errorf("Expected chord 'J+K' with 2 keys, got %q %q", got[0].Text, got[0].Keys)
+	}
+	if got[1].Text != "L" || len(got[1].Keys) != 0 {
+		t.Errorf("Expected single key 'L', got %q %q", got[1].Text, got[1].Keys)
+	}
+}
+
+func TestProcessor_ExcludedClasses(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ExcludedClasses = []string{"letters", "function"}
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_A, Name: "A", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_F5, Name: "F5", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_1, Name: "1", State: input.KeyPressed})
+
+	history := proc.History()
+	if len(history) != 1 || history[0].Text != "1" {
+		t.Errorf("Expected only '1' in history, got %+v", history)
+	}
+}
+
+func TestProcessor_ShortcutsOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		mod      uint16
+		code     uint16
+		key      string
+		expected string
+	}{
+		{"plain letter hidden", 0, input.KEY_A, "A", ""},
+		{"shifted letter hidden", input.KEY_LEFTSHIFT, input.KEY_A, "A", ""},
+		{"space hidden", 0, input.KEY_SPACE, "Space", ""},
+		{"ctrl combo shown", input.KEY_LEFTCTRL, input.KEY_A, "A", "Ctrl+A"},
+		{"super combo shown", input.KEY_LEFTMETA, input.KEY_1, "1", "Super+1"},
+		{"named key shown", 0, input.KEY_ENTER, "Enter", "Enter"},
+		{"navigation shown", 0, input.KEY_LEFT, "Left", "Left"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ShowHeldKeys = false
+			cfg.ShortcutsOnly = true
+
+			proc := New(cfg)
+			defer proc.Stop()
+
+			if tt.mod != 0 {
+				proc.handleKeyEvent(input.KeyEvent{Code: tt.mod, Name: input.GetKeyName(tt.mod), State: input.KeyPressed})
+			}
+			proc.handleKeyEvent(input.KeyEvent{Code: tt.code, Name: tt.key, State: input.KeyPressed})
+
+			got := ""
+			if history := proc.History(); len(history) > 0 {
+				got = history[len(history)-1].Text
+			}
+			if got != tt.expected {
+				t.Errorf("Got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessor_ExcludeUnmodifiedPrintable(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ExcludedClasses = []string{UnmodifiedPrintable}
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_S, Name: "S", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_S, Name: "S", State: input.KeyPressed})
+
+	history := proc.History()
+	if len(history) != 1 || history[0].Text != "Ctrl+S" {
+		t.Errorf("Expected only 'Ctrl+S' in history, got %+v", history)
+	}
+}
+
+func TestProcessor_MaskPrintable(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.MaskPrintable = true
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_H, Name: "H", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_1, Name: "1", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFT, Name: "Left", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_C, Name: "C", State: input.KeyPressed})
+
+	expected := []string{MaskGlyph, MaskGlyph, "Left", "Ctrl+C"}
+	history := proc.History()
+	if len(history) != len(expected) {
+		t.Fatalf("History = %+v, want %q", history, expected)
+	}
+	for i, h := range history {
+		if h.Text != expected[i] {
+			t.Errorf("History[%d] = %q, want %q", i, h.Text, expected[i])
+		}
+	}
+}
+
+func TestProcessor_SetMasked(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.SetMasked(true)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_A, Name: "A", State: input.KeyPressed})
+	proc.SetMasked(false)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_B, Name: "B", State: input.KeyPressed})
+
+	history := proc.History()
+	if len(history) != 2 || history[0].Text != MaskGlyph || history[1].Text != "B" {
+		t.Errorf("Expected [%s B], got %+v", MaskGlyph, history)
+	}
+}
+
+func press(proc *Processor, codes ...uint16) {
+	for _, code := range codes {
+		proc.handleKeyEvent(input.KeyEvent{Code: code, Name: input.GetKeyName(code), State: input.KeyPressed})
+		proc.handleKeyEvent(input.KeyEvent{Code: code, Name: input.GetKeyName(code), State: input.KeyReleased})
+	}
+}
+
+func historyTexts(proc *Processor) []string {
+	var texts []string
+	for _, h := range proc.History() {
+		texts = append(texts, h.Text)
+	}
+	return texts
+}
+
+func TestVimCommand(t *testing.T) {
+	tests := []struct {
+		keys     string
+		complete bool
+		insert   bool
+	}{
+		{"j", true, false},
+		{"3", false, false},
+		{"3j", true, false},
+		{"d", false, false},
+		{"dd", true, false},
+		{"3dd", true, false},
+		{"d2w", true, false},
+		{"ci", false, false},
+		{"ciw", true, true},
+		{"cw", true, true},
+		{"i", true, true},
+		{"o", true, true},
+		{"g", false, false},
+		{"gg", true, false},
+		{"fx", true, false},
+		{`"a`, false, false},
+		{`"ayy`, true, false},
+		{"0", true, false},
+		{"10G", true, false},
+	}
+
+	for _, tt := range tests {
+		complete, insert := vimCommand([]rune(tt.keys))
+		if complete != tt.complete || insert != tt.insert {
+			t.Errorf("vimCommand(%q) = %v, %v; want %v, %v", tt.keys, complete, insert, tt.complete, tt.insert)
+		}
+	}
+}
+
+func TestHelixCommand(t *testing.T) {
+	tests := []struct {
+		keys     string
+		complete bool
+		insert   bool
+	}{
+		{"w", true, false},
+		{"d", true, false},
+		{"c", true, true},
+		{"mi", false, false},
+		{"miw", true, false},
+		{"mm", true, false},
+		{" ", false, false},
+		{" f", true, false},
+	}
+
+	for _, tt := range tests {
+		complete, insert := helixCommand([]rune(tt.keys))
+		if complete != tt.complete || insert != tt.insert {
+			t.Errorf("helixCommand(%q) = %v, %v; want %v, %v", tt.keys, complete, insert, tt.complete, tt.insert)
+		}
+	}
+}
+
+func TestProcessor_ModalVim(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 10
+
+	proc := New(cfg)
+	defer proc.Stop()
+	proc.SetModalEditor(EditorVim)
+
+	press(proc, input.KEY_C, input.KEY_I, input.KEY_W)
+	press(proc, input.KEY_H, input.KEY_E, input.KEY_Y)
+	press(proc, input.KEY_ESC)
+	press(proc, input.KEY_3, input.KEY_D, input.KEY_D)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+	press(proc, input.KEY_SEMICOLON)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+	press(proc, input.KEY_W, input.KEY_Q, input.KEY_ENTER)
+
+	expected := []string{"ciw", "Insert: 3 keys", "Esc", "3dd", ":wq"}
+	got := historyTexts(proc)
+	if len(got) != len(expected) {
+		t.Fatalf("History = %q, want %q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestProcessor_ModalMasked(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 10
+	cfg.Rules = []Rule{{Class: "digits", Action: RuleMask}}
+
+	proc := New(cfg)
+	defer proc.Stop()
+	proc.SetModalEditor(EditorVim)
+
+	press(proc, input.KEY_3, input.KEY_D, input.KEY_D)
+	proc.SetMasked(true)
+	press(proc, input.KEY_SLASH, input.KEY_P, input.KEY_A, input.KEY_BACKSPACE, input.KEY_S, input.KEY_ENTER)
+	press(proc, input.KEY_C, input.KEY_I, input.KEY_W)
+
+	mask := func(n int) string { return strings.Repeat(MaskGlyph, n) }
+	expected := []string{MaskGlyph + "dd", mask(3), mask(3)}
+	got := historyTexts(proc)
+	if len(got) != len(expected) {
+		t.Fatalf("History = %q, want %q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestProcessor_ModalInsertHide(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ModalInsertMode = InsertHide
+
+	proc := New(cfg)
+	defer proc.Stop()
+	proc.SetModalEditor(EditorHelix)
+
+	press(proc, input.KEY_I, input.KEY_A, input.KEY_B, input.KEY_ESC, input.KEY_W)
+
+	expected := []string{"i", "Esc", "w"}
+	got := historyTexts(proc)
+	if len(got) != len(expected) {
+		t.Fatalf("History = %q, want %q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestProcessor_StickyModifiers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.StickyModifiers = true
+	cfg.StickyTimeout = 30 * time.Millisecond
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	press(proc, input.KEY_LEFTCTRL, input.KEY_LEFTSHIFT, input.KEY_T, input.KEY_X)
+
+	var armed []string
+	for len(proc.Events()) > 0 {
+		if event := <-proc.Events(); event.IsArmed {
+			armed = append(armed, event.Text)
+		}
+	}
+	expectedArmed := []string{"Ctrl+…", "Ctrl+Shift+…", ""}
+	if len(armed) != len(expectedArmed) {
+		t.Fatalf("Armed events = %q, want %q", armed, expectedArmed)
+	}
+	for i := range expectedArmed {
+		if armed[i] != expectedArmed[i] {
+			t.Errorf("Armed[%d] = %q, want %q", i, armed[i], expectedArmed[i])
+		}
+	}
+
+	got := historyTexts(proc)
+	if len(got) != 2 || got[0] != "Ctrl+Shift+T" || got[1] != "X" {
+		t.Errorf("History = %q, want [Ctrl+Shift+T X]", got)
+	}
+
+	press(proc, input.KEY_LEFTALT)
+	time.Sleep(60 * time.Millisecond)
+	press(proc, input.KEY_Y)
+
+	got = historyTexts(proc)
+	if got[len(got)-1] != "Y" {
+		t.Errorf("Sticky modifier should expire, got %q", got[len(got)-1])
+	}
+}
+
+func TestProcessor_StickyModifierHeldNotArmed(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.StickyModifiers = true
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+	press(proc, input.KEY_C)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyReleased})
+	press(proc, input.KEY_V)
+
+	got := historyTexts(proc)
+	if len(got) != 2 || got[0] != "Ctrl+C" || got[1] != "V" {
+		t.Errorf("History = %q, want [Ctrl+C V]", got)
+	}
+}
+
+func testComposeTable() *compose.Table {
+	table := compose.NewTable()
+	table.Add([]string{"Multi_key", "apostrophe", "e"}, "é")
+	table.Add([]string{"Multi_key", "o", "c"}, "©")
+	table.Add([]string{"dead_acute", "e"}, "é")
+	return table
+}
+
+func TestProcessor_ComposeSequence(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ComposeSequences = true
+	cfg.ComposeTable = testComposeTable()
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	press(proc, input.KEY_COMPOSE, input.KEY_APOSTROPHE, input.KEY_E)
+	press(proc, input.KEY_COMPOSE, input.KEY_X, input.KEY_A)
+
+	got := historyTexts(proc)
+	expected := []string{"Compose ' e → é", "Compose x", "A"}
+	if len(got) != len(expected) {
+		t.Fatalf("History = %q, want %q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestProcessor_DeadKeySequence(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ComposeSequences = true
+	cfg.DeadKeys = []string{"'"}
+	cfg.ComposeTable = testComposeTable()
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	press(proc, input.KEY_APOSTROPHE, input.KEY_E, input.KEY_COMMA)
+
+	got := historyTexts(proc)
+	if len(got) != 2 || got[0] != "' e → é" || got[1] != "," {
+		t.Errorf("History = %q, want [' e → é ,]", got)
+	}
+}
+
+func TestProcessor_UnicodeEntry(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ComposeSequences = true
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+	press(proc, input.KEY_U)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyReleased})
+	press(proc, input.KEY_E, input.KEY_9, input.KEY_SPACE)
+
+	got := historyTexts(proc)
+	if len(got) != 1 || got[0] != "Ctrl+Shift+U e9 → é" {
+		t.Errorf("History = %q, want [Ctrl+Shift+U e9 → é]", got)
+	}
+}
+
+func TestProcessor_SequenceNames(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ComposeSequences = true
+	cfg.ComposeTable = testComposeTable()
+	cfg.KeyNames = map[string]string{"Ctrl": "Strg", "Shift": "Umschalt", "Compose": "Verfassen"}
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	press(proc, input.KEY_COMPOSE, input.KEY_APOSTROPHE, input.KEY_E)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+	press(proc, input.KEY_U)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyReleased})
+	press(proc, input.KEY_E, input.KEY_9, input.KEY_SPACE)
+
+	got := historyTexts(proc)
+	expected := []string{"Verfassen ' e → é", "Strg+Umschalt+U e9 → é"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("History = %q, want %q", got, expected)
+	}
+
+	cfg.KeyNames = nil
+	cfg.Notation = NotationEmacs
+	proc = New(cfg)
+	defer proc.Stop()
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+	press(proc, input.KEY_U)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyReleased})
+	press(proc, input.KEY_E, input.KEY_9, input.KEY_SPACE)
+
+	if got := historyTexts(proc); len(got) != 1 || got[0] != "C-S-u e9 → é" {
+		t.Errorf("History = %q, want [C-S-u e9 → é]", got)
+	}
+}
+
+func TestProcessor_NumLockKeypadNames(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.NumLock = false
+	cfg.HistoryCount = 10
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	press(proc, input.KEY_KP7, input.KEY_KP3, input.KEY_KPDOT, input.KEY_KPPLUS)
+	press(proc, input.KEY_NUMLOCK, input.KEY_KP7)
+
+	got := historyTexts(proc)
+	expected := []string{"Home", "PageDown", "Delete", "Num+", "NumLock", "Num7"}
+	if len(got) != len(expected) {
+		t.Fatalf("History = %q, want %q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestProcessor_NumLockWhileKeypadHeld(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.NumLock = false
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_KP7, Name: "KP7", State: input.KeyPressed})
+	press(proc, input.KEY_NUMLOCK)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_KP7, Name: "KP7", State: input.KeyReleased})
+
+	if len(proc.held) != 0 {
+		t.Errorf("held = %d keys after release, want 0", len(proc.held))
+	}
+}
+
+func TestProcessor_KeypadAsDigits(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.KeypadAsDigits = true
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	press(proc, input.KEY_KP7, input.KEY_KPENTER)
+
+	got := historyTexts(proc)
+	if len(got) != 2 || got[0] != "7" || got[1] != "NumEnter" {
+		t.Errorf("History = %q, want [7 NumEnter]", got)
+	}
+}
+
+func TestProcessor_FocusChanged(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ResetOnFocusChange = true
+	cfg.FocusDivider = DividerLabel
+	cfg.HistoryCount = 10
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.FocusChanged("kitty")
+	press(proc, input.KEY_L, input.KEY_S)
+	proc.FocusChanged("kitty")
+	if got := historyTexts(proc); len(got) != 2 {
+		t.Fatalf("History after refocusing the same app = %q, want 2 entries", got)
+	}
+
+	proc.FocusChanged("firefox")
+	press(proc, input.KEY_J)
+
+	history := proc.History()
+	if len(history) != 2 {
+		t.Fatalf("History length = %d, want 2", len(history))
+	}
+	if !history[0].IsDivider || history[0].Text != "firefox" {
+		t.Errorf("History[0] = %+v, want firefox divider", history[0])
+	}
+	if history[1].Text != "J" {
+		t.Errorf("History[1] = %q, want J", history[1].Text)
+	}
+}
+
+func TestProcessor_FocusChangedDropsChord(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ResetOnFocusChange = true
+	cfg.DetectChords = true
+	cfg.ChordWindow = time.Second
+
+	proc := New(cfg)
+	defer proc.Stop()
+	sub := proc.Subscribe("test", 16, DropOldest)
+
+	proc.FocusChanged("kitty")
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_J, Name: "J", State: input.KeyPressed})
+	proc.FocusChanged("firefox")
+
+	for len(sub.Events()) > 0 {
+		if event := <-sub.Events(); event.Text == "J" {
+			t.Errorf("Pending chord was shown on focus change: %+v", event)
+		}
+	}
+	if got := historyTexts(proc); len(got) != 0 {
+		t.Errorf("History = %q, want empty", got)
+	}
+}
+
+func TestProcessor_FocusChangedDisabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.FocusChanged("kitty")
+	press(proc, input.KEY_L)
+	proc.FocusChanged("firefox")
+
+	if got := historyTexts(proc); len(got) != 1 || got[0] != "L" {
+		t.Errorf("History = %q, want [L]", got)
+	}
+}
+
+func TestProcessor_HoldDuration(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShowHoldDuration = true
+
+		proc := New(cfg)
+		defer proc.Stop()
+
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_SPACE, Name: "Space", State: input.KeyPressed})
+		time.Sleep(1450 * time.Millisecond)
+		synctest.Wait()
+
+		var last string
+		for len(proc.Events()) > 0 {
+			if event := <-proc.Events(); event.IsHeld {
+				last = event.Text
+			}
+		}
+		if last != "Space 1.4s" {
+			t.Errorf("Live held text = %q, want %q", last, "Space 1.4s")
+		}
+
+		time.Sleep(150 * time.Millisecond)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_SPACE, Name: "Space", State: input.KeyReleased})
+
+		if got := historyTexts(proc); len(got) != 1 || got[0] != "Space 1.6s" {
+			t.Errorf("History after release = %q, want [Space 1.6s]", got)
+		}
+	})
+}
+
+func TestProcessor_EntryLifetimes(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShowHeldKeys = false
+		cfg.ResetTimeout = 2 * time.Second
+		cfg.PrintableLifetime = time.Second
+
+		proc := New(cfg)
+		defer proc.Stop()
+
+		press(proc, input.KEY_A, input.KEY_ENTER)
+		for len(proc.Events()) > 0 {
+			<-proc.Events()
+		}
+
+		time.Sleep(time.Second - FadeDuration)
+		synctest.Wait()
+		event := <-proc.Events()
+		if !event.IsFading || event.Text != "A" {
+			t.Errorf("Event = %+v, want A fading", event)
+		}
+		if len(event.History) != 2 || !event.History[0].IsFading || event.History[1].IsFading {
+			t.Errorf("Snapshot = %+v, want A fading and Enter not", event.History)
+		}
+
+		time.Sleep(FadeDuration)
+		synctest.Wait()
+		if event := <-proc.Events(); !event.IsExpired {
+			t.Errorf("Event = %+v, want expired", event)
+		}
+		if got := historyTexts(proc); len(got) != 1 || got[0] != "Enter" {
+			t.Errorf("History = %q, want [Enter]", got)
+		}
+
+		time.Sleep(time.Second)
+		synctest.Wait()
+		var reset bool
+		for len(proc.Events()) > 0 {
+			reset = (<-proc.Events()).IsReset
+		}
+		if !reset || len(proc.History()) != 0 {
+			t.Errorf("Expected a reset once every entry expired, history = %q", historyTexts(proc))
+		}
+	})
+}
+
+func TestProcessor_Recall(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShowHeldKeys = false
+		cfg.HistoryCount = 2
+		cfg.RecallHotkey = "Super+Shift+H"
+		cfg.RecallCount = 3
+
+		proc := New(cfg)
+		defer proc.Stop()
+
+		press(proc, input.KEY_A, input.KEY_B, input.KEY_C, input.KEY_D)
+		time.Sleep(cfg.ResetTimeout + time.Second)
+		synctest.Wait()
+		for len(proc.Events()) > 0 {
+			<-proc.Events()
+		}
+
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyPressed})
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+		press(proc, input.KEY_H)
+
+		event := <-proc.Events()
+		if !event.IsRecall || len(event.Recall) != 3 {
+			t.Fatalf("Event = %+v, want recall of 3 entries", event)
+		}
+		for i, want := range []string{"B", "C", "D"} {
+			if event.Recall[i].Text != want {
+				t.Errorf("Recall[%d] = %q, want %q", i, event.Recall[i].Text, want)
+			}
+		}
+		if len(proc.History()) != 0 {
+			t.Errorf("Recall hotkey was recorded: %q", historyTexts(proc))
+		}
+
+		time.Sleep(cfg.RecallDuration)
+		synctest.Wait()
+		if event := <-proc.Events(); !event.IsRecall || len(event.Recall) != 0 {
+			t.Errorf("Event = %+v, want empty recall", event)
+		}
+	})
+}
+
+func TestProcessor_Hotkeys(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 10
+	cfg.Hotkeys = map[string]string{
+		"Super+F9":  ActionTogglePause,
+		"Super+F10": ActionClearHistory,
+	}
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	actions := make(chan string, 2)
+	proc.OnHotkey(func(action string) { actions <- action })
+
+	press(proc, input.KEY_A)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyPressed})
+	press(proc, input.KEY_F9)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyReleased})
+
+	if action := <-actions; action != ActionTogglePause {
+		t.Errorf("Action = %q, want %q", action, ActionTogglePause)
+	}
+	if got := historyTexts(proc); len(got) != 1 || got[0] != "A" {
+		t.Errorf("History = %q, want [A]", got)
+	}
+
+	proc.SetPaused(true)
+	press(proc, input.KEY_B)
+	proc.SetPaused(false)
+	press(proc, input.KEY_C)
+	if got := historyTexts(proc); len(got) != 2 || got[1] != "C" {
+		t.Errorf("History = %q, want [A C]", got)
+	}
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyPressed})
+	press(proc, input.KEY_F10)
+	if action := <-actions; action != ActionClearHistory {
+		t.Errorf("Action = %q, want %q", action, ActionClearHistory)
+	}
+	if got := historyTexts(proc); len(got) != 0 {
+		t.Errorf("History after clear = %q, want empty", got)
+	}
+}
+
+func TestProcessor_HotkeyModifiers(t *testing.T) {
+	superF9 := func(proc *Processor) {
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyPressed})
+		press(proc, input.KEY_F9)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyReleased})
+	}
+
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 10
+	cfg.StickyModifiers = true
+	cfg.Hotkeys = map[string]string{"Super+F9": ActionCycleProfile}
+
+	proc := New(cfg)
+	superF9(proc)
+	press(proc, input.KEY_A)
+	if got := historyTexts(proc); len(got) != 1 || got[0] != "A" {
+		t.Errorf("History with sticky modifiers = %q, want [A]", got)
+	}
+	proc.Stop()
+
+	cfg.StickyModifiers = false
+	cfg.ShowModifierOnly = true
+	proc = New(cfg)
+	defer proc.Stop()
+	superF9(proc)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyPressed})
+	press(proc, input.KEY_A)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyReleased})
+	expected := []string{"Super", "Super+A"}
+	if got := historyTexts(proc); len(got) != 2 || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("History with show_modifier_only = %q, want %q", got, expected)
+	}
+}
+
+func TestProcessor_SetConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 10
+	cfg.Hotkeys = map[string]string{"Super+F11": ActionCycleProfile}
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	actions := make(chan string, 1)
+	proc.OnHotkey(func(action string) { actions <- action })
+
+	press(proc, input.KEY_A)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyPressed})
+	press(proc, input.KEY_F11)
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTMETA, Name: "Super", State: input.KeyReleased})
+	if action := <-actions; action != ActionCycleProfile {
+		t.Errorf("Action = %q, want %q", action, ActionCycleProfile)
+	}
+
+	profile := cfg
+	profile.ExcludedKeys = []string{"b"}
+	profile.Labels = map[string]string{"Enter": "⏎"}
+	proc.SetConfig(profile)
+	press(proc, input.KEY_B, input.KEY_ENTER)
+
+	got := historyTexts(proc)
+	expected := []string{"A", "⏎"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("History = %q, want %q", got, expected)
+	}
+}
+
+func TestProcessor_Rate(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShowHeldKeys = false
+		cfg.ShowRate = true
+		cfg.RateWindow = 10 * time.Second
+		cfg.ExcludedKeys = []string{"Tab"}
+
+		proc := New(cfg)
+		defer proc.Stop()
+
+		lastStats := func() DisplayEvent {
+			var stats DisplayEvent
+			for len(proc.Events()) > 0 {
+				if event := <-proc.Events(); event.IsStats {
+					stats = event
+				}
+			}
+			return stats
+		}
+
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+		press(proc, input.KEY_H, input.KEY_E, input.KEY_L, input.KEY_L, input.KEY_O, input.KEY_TAB, input.KEY_ENTER)
+
+		if stats := lastStats(); stats.KPM != 36 || stats.WPM != 6 {
+			t.Errorf("Rate = %d KPM %d WPM, want 36 KPM 6 WPM", stats.KPM, stats.WPM)
+		}
+
+		proc.SetPaused(true)
+		time.Sleep(time.Minute)
+		proc.SetPaused(false)
+		press(proc, input.KEY_A)
+		if stats := lastStats(); stats.KPM != 42 {
+			t.Errorf("KPM after pause = %d, want 42", stats.KPM)
+		}
+
+		time.Sleep(11 * time.Second)
+		synctest.Wait()
+		if stats := lastStats(); !stats.IsStats || stats.KPM != 0 {
+			t.Errorf("Rate after window = %+v, want 0 KPM", stats)
+		}
+	})
+}
+
+func TestProcessor_RateResumeTicks(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShowHeldKeys = false
+		cfg.ShowRate = true
+		cfg.RateWindow = 10 * time.Second
+
+		proc := New(cfg)
+		defer proc.Stop()
+		sub := proc.Subscribe("test", 64, DropOldest)
+
+		press(proc, input.KEY_A)
+		proc.SetPaused(true)
+		time.Sleep(time.Minute)
+		proc.SetPaused(false)
+
+		time.Sleep(11 * time.Second)
+		synctest.Wait()
+		var stats DisplayEvent
+		for len(sub.Events()) > 0 {
+			if event := <-sub.Events(); event.IsStats {
+				stats = event
+			}
+		}
+		if !stats.IsStats || stats.KPM != 0 {
+			t.Errorf("Rate after resuming without keys = %+v, want it to decay to 0 KPM", stats)
+		}
+	})
+}
+
+func TestProcessor_RateZeroWindow(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ShowRate = true
+	cfg.RateWindow = 0
+
+	proc := New(cfg)
+	out := proc.Events()
+	defer proc.Stop()
+
+	press(proc, input.KEY_A)
+	var stats DisplayEvent
+	for len(out) > 0 {
+		if event := <-out; event.IsStats {
+			stats = event
+		}
+	}
+	if stats.KPM != 6 {
+		t.Errorf("KPM = %d, want 6 over the default window", stats.KPM)
+	}
+}
+func TestProcessor_ModifierBar(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.ShowModifierBar = true
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyHeld})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+	proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyReleased})
+
+	var bars []string
+	for len(proc.Events()) > 0 {
+		if event := <-proc.Events(); event.IsModBar {
+			bars = append(bars, strings.Join(event.Modifiers, "+"))
+		}
+	}
+
+	expected := []string{"Ctrl", "Ctrl+Shift", "Shift"}
+	if len(bars) != len(expected) {
+		t.Fatalf("Modifier bar events = %q, want %q", bars, expected)
+	}
+	for i := range expected {
+		if bars[i] != expected[i] {
+			t.Errorf("Modifier bar[%d] = %q, want %q", i, bars[i], expected[i])
+		}
+	}
+	if len(proc.History()) != 0 {
+		t.Errorf("Modifier bar events were recorded in history: %q", historyTexts(proc))
+	}
+}
+
+func TestProcessor_Coaching(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.Coaching = true
+	cfg.CoachRules = []CoachRule{
+		{Keys: []string{"Left"}, Repeat: 3, Hint: "Ctrl+Left jumps by word"},
+		{Keys: []string{"Home", "Shift+End"}, Apps: []string{"code"}, Hint: "Ctrl+L selects the whole line"},
+	}
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	hints := func() []string {
+		var result []string
+		for len(proc.Events()) > 0 {
+			if event := <-proc.Events(); event.IsHint {
+				result = append(result, event.Text)
+			}
+		}
+		return result
+	}
+
+	press(proc, input.KEY_LEFT, input.KEY_LEFT, input.KEY_RIGHT, input.KEY_LEFT, input.KEY_LEFT)
+	if got := hints(); len(got) != 0 {
+		t.Errorf("Hints for an interrupted run = %q, want none", got)
+	}
+	press(proc, input.KEY_LEFT)
+	if got := hints(); len(got) != 1 || got[0] != "Ctrl+Left jumps by word" {
+		t.Errorf("Hints = %q, want the Ctrl+Left hint", got)
+	}
+	press(proc, input.KEY_LEFT, input.KEY_LEFT, input.KEY_LEFT)
+	if got := hints(); len(got) != 0 {
+		t.Errorf("Hints during cooldown = %q, want none", got)
+	}
+
+	homeShiftEnd := func() {
+		press(proc, input.KEY_HOME)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+		press(proc, input.KEY_END)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+	}
+
+	proc.FocusChanged("firefox")
+	homeShiftEnd()
+	if got := hints(); len(got) != 0 {
+		t.Errorf("Hints in another app = %q, want none", got)
+	}
+	proc.FocusChanged("Code")
+	homeShiftEnd()
+	if got := hints(); len(got) != 1 || got[0] != "Ctrl+L selects the whole line" {
+		t.Errorf("Hints = %q, want the Ctrl+L hint", got)
+	}
+}
+
+func TestProcessor_RetractSecrets(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 20
+	cfg.ScrollbackCount = 20
+	cfg.RetractSecrets = true
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	shifted := func(code uint16) {
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+		press(proc, code)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+	}
+
+	// "ls" is not a secret.
+	press(proc, input.KEY_L, input.KEY_S, input.KEY_ENTER)
+
+	// "hX7!q2Rz" is.
+	press(proc, input.KEY_H)
+	shifted(input.KEY_X)
+	press(proc, input.KEY_7)
+	shifted(input.KEY_1)
+	press(proc, input.KEY_Q, input.KEY_2)
+	shifted(input.KEY_R)
+	press(proc, input.KEY_Z, input.KEY_ENTER)
+
+	got := historyTexts(proc)
+	expected := []string{"L", "S", "Enter", i18n.T("redacted"), "Enter"}
+	if len(got) != len(expected) {
+		t.Fatalf("History = %q, want %q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+		}
+	}
+	if n := len(proc.scrollback); n != len(expected) {
+		t.Errorf("Scrollback length = %d, want %d", n, len(expected))
+	}
+}
+
+func TestProcessor_RetractSecretsWithChords(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShowHeldKeys = false
+		cfg.HistoryCount = 20
+		cfg.RetractSecrets = true
+		cfg.DetectChords = true
+		cfg.ChordWindow = 30 * time.Millisecond
+
+		proc := New(cfg)
+		defer proc.Stop()
+
+		key := func(code uint16, state input.KeyState) {
+			proc.handleKeyEvent(input.KeyEvent{Code: code, Name: input.GetKeyName(code), State: state, Timestamp: time.Now()})
+		}
+		typeKeys := func(pause time.Duration, codes ...uint16) {
+			for _, code := range codes {
+				if code == input.KEY_LEFTSHIFT {
+					key(code, input.KeyPressed)
+					continue
+				}
+				key(code, input.KeyPressed)
+				key(code, input.KeyReleased)
+				key(input.KEY_LEFTSHIFT, input.KeyReleased)
+				time.Sleep(pause)
+				synctest.Wait()
+			}
+		}
+
+		// "hX7!q2Rz", then Enter after the last chord was shown.
+		typeKeys(100*time.Millisecond, input.KEY_H, input.KEY_LEFTSHIFT, input.KEY_X, input.KEY_7,
+			input.KEY_LEFTSHIFT, input.KEY_1, input.KEY_Q, input.KEY_2, input.KEY_LEFTSHIFT, input.KEY_R, input.KEY_Z, input.KEY_ENTER)
+		// The same again, with Enter inside the chord window of the last key.
+		typeKeys(100*time.Millisecond, input.KEY_H, input.KEY_LEFTSHIFT, input.KEY_X, input.KEY_7,
+			input.KEY_LEFTSHIFT, input.KEY_1, input.KEY_Q, input.KEY_2, input.KEY_LEFTSHIFT, input.KEY_R)
+		typeKeys(0, input.KEY_Z, input.KEY_ENTER)
+		time.Sleep(100 * time.Millisecond)
+		synctest.Wait()
+
+		got := historyTexts(proc)
+		expected := []string{i18n.T("redacted"), "Enter", i18n.T("redacted")}
+		if len(got) != len(expected) {
+			t.Fatalf("History = %q, want %q", got, expected)
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+			}
+		}
+	})
+}
+
+func TestLooksLikeSecret(t *testing.T) {
+	rules := SecretRules{MinLength: 8, MinEntropyBits: 24, MinClasses: 3}
+	tests := []struct {
+		text   string
+		secret bool
+	}{
+		{"hX7!q2Rz", true},
+		{"Tr0ub4dor&3", true},
+		{"password", false},
+		{"Passw0rd", false},
+		{"git push origin", false},
+		{"aA1!", false},
+	}
+
+	for _, tt := range tests {
+		if got := looksLikeSecret([]rune(tt.text), rules); got != tt.secret {
+			t.Errorf("looksLikeSecret(%q) = %v, want %v", tt.text, got, tt.secret)
+		}
+	}
+}
+
+func TestProcessor_Rules(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 20
+	cfg.Rules = []Rule{
+		{Combo: "Ctrl+S", Action: RuleAnnotate, Label: "(save)"},
+		{Combo: "Ctrl+Shift+P", Apps: []string{"code"}, Action: RuleRelabel, Label: "Command Palette"},
+		{Class: "navigation", Repeat: 3, Action: RuleHide},
+		{Class: "digits", Action: RuleMask},
+		{Combo: "Esc", Action: RuleExtend, Lifetime: time.Minute},
+	}
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	ctrl := func(codes ...uint16) {
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+		press(proc, codes...)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyReleased})
+	}
+	ctrlShiftP := func() {
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyPressed})
+		ctrl(input.KEY_P)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTSHIFT, Name: "Shift", State: input.KeyReleased})
+	}
+
+	ctrl(input.KEY_S)
+	proc.FocusChanged("firefox")
+	ctrlShiftP()
+	proc.FocusChanged("code")
+	ctrlShiftP()
+	press(proc, input.KEY_DOWN, input.KEY_DOWN, input.KEY_DOWN, input.KEY_DOWN, input.KEY_UP)
+	press(proc, input.KEY_4, input.KEY_ESC)
+
+	got := historyTexts(proc)
+	expected := []string{"Ctrl+S (save)", "Ctrl+Shift+P", "Command Palette", "Down", "Down", "Up", MaskGlyph, "Esc"}
+	if len(got) != len(expected) {
+		t.Fatalf("History = %q, want %q", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+		}
+	}
+
+	history := proc.History()
+	if lifetime := history[len(history)-1].lifetime; lifetime != time.Minute {
+		t.Errorf("Extended lifetime = %v, want %v", lifetime, time.Minute)
+	}
+}
+
+func TestProcessor_RulesWithChords(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.ShowHeldKeys = false
+		cfg.HistoryCount = 20
+		cfg.DetectChords = true
+		cfg.Rules = []Rule{
+			{Combo: "Ctrl+S", Action: RuleAnnotate, Label: "(save)"},
+			{Combo: "Esc", Action: RuleExtend, Lifetime: time.Minute},
+		}
+
+		proc := New(cfg)
+		defer proc.Stop()
+
+		key := func(code uint16, state input.KeyState) {
+			proc.handleKeyEvent(input.KeyEvent{Code: code, Name: input.GetKeyName(code), State: state, Timestamp: time.Now()})
+		}
+		key(input.KEY_J, input.KeyPressed)
+		key(input.KEY_LEFTCTRL, input.KeyPressed)
+		key(input.KEY_S, input.KeyPressed)
+		key(input.KEY_S, input.KeyReleased)
+		key(input.KEY_LEFTCTRL, input.KeyReleased)
+		key(input.KEY_J, input.KeyReleased)
+		key(input.KEY_ESC, input.KeyPressed)
+		key(input.KEY_ESC, input.KeyReleased)
+		time.Sleep(time.Second)
+		synctest.Wait()
+
+		got := historyTexts(proc)
+		expected := []string{"J", "Ctrl+S (save)", "Esc"}
+		if len(got) != len(expected) {
+			t.Fatalf("History = %q, want %q", got, expected)
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("History[%d] = %q, want %q", i, got[i], expected[i])
+			}
+		}
+		if lifetime := proc.History()[2].lifetime; lifetime != time.Minute {
+			t.Errorf("Extended lifetime = %v, want %v", lifetime, time.Minute)
+		}
+	})
+}
+
+func TestProcessor_Labels(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 20
+	cfg.Glyphs = GlyphsMacStyle
+	cfg.Labels = map[string]string{"Enter": "Return"}
+	cfg.KeyNames = map[string]string{"Enter": "Eingabe", "Insert": "Einfg", "Ctrl": "Strg"}
+	cfg.ExcludedKeys = []string{"Ctrl+C"}
+	cfg.MaskPrintable = true
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	ctrl := func(codes ...uint16) {
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+		press(proc, codes...)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyReleased})
+	}
+	ctrl(input.KEY_S, input.KEY_C)
+	press(proc, input.KEY_ENTER, input.KEY_BACKSPACE, input.KEY_INSERT, input.KEY_A)
+
+	history := proc.History()
+	expected := []struct{ text, combo string }{
+		{"⌃+S", "Ctrl+S"},
+		{"Return", "Enter"},
+		{"⌫", "Backspace"},
+		{"Einfg", "Insert"},
+		{MaskGlyph, ""},
+	}
+	if len(history) != len(expected) {
+		t.Fatalf("History = %q, want %v", historyTexts(proc), expected)
+	}
+	for i, want := range expected {
+		if history[i].Text != want.text || history[i].Combo != want.combo {
+			t.Errorf("History[%d] = %q (%q), want %q (%q)", i, history[i].Text, history[i].Combo, want.text, want.combo)
+		}
+	}
+}
+
+func TestProcessor_Notation(t *testing.T) {
+	tests := []struct {
+		notation  string
+		order     []string
+		separator string
+		expected  []string
+	}{
+		{NotationDefault, nil, "", []string{"Ctrl+Shift+X", "Alt+Enter", "X"}},
+		{NotationDefault, []string{"Shift", "Ctrl"}, " + ", []string{"Shift + Ctrl + X", "Alt + Enter", "X"}},
+		{NotationEmacs, nil, "", []string{"C-S-x", "M-RET", "x"}},
+		{NotationVim, nil, "", []string{"<C-S-x>", "<M-CR>", "x"}},
+		{NotationMac, nil, "", []string{"⌃⇧X", "⌥⏎", "X"}},
+		{NotationKeycaps, nil, "", []string{"Ctrl Shift X", "Alt Enter", "X"}},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.ShowHeldKeys = false
+		cfg.Notation = tt.notation
+		cfg.ModifierOrder = tt.order
+		cfg.Separator = tt.separator
+		proc := New(cfg)
+
+		hold := func(code uint16, name string, codes ...uint16) {
+			proc.handleKeyEvent(input.KeyEvent{Code: code, Name: name, State: input.KeyPressed})
+			press(proc, codes...)
+			proc.handleKeyEvent(input.KeyEvent{Code: code, Name: name, State: input.KeyReleased})
+		}
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyPressed})
+		hold(input.KEY_LEFTSHIFT, "Shift", input.KEY_X)
+		proc.handleKeyEvent(input.KeyEvent{Code: input.KEY_LEFTCTRL, Name: "Ctrl", State: input.KeyReleased})
+		hold(input.KEY_LEFTALT, "Alt", input.KEY_ENTER)
+		press(proc, input.KEY_X)
+
+		got := historyTexts(proc)
+		if len(got) != len(tt.expected) {
+			t.Fatalf("%s: history = %q, want %q", tt.notation, got, tt.expected)
+		}
+		for i := range tt.expected {
+			if got[i] != tt.expected[i] {
+				t.Errorf("%s: history[%d] = %q, want %q", tt.notation, i, got[i], tt.expected[i])
+			}
+		}
+
+		history := proc.History()
+		if history[0].Combo != "Ctrl+Shift+X" {
+			t.Errorf("%s: combo = %q, want Ctrl+Shift+X", tt.notation, history[0].Combo)
+		}
+		if keys := history[0].Keys; tt.notation == NotationKeycaps && len(keys) != 3 {
+			t.Errorf("%s: keys = %q, want 3 keycaps", tt.notation, keys)
+		}
+		proc.Stop()
+	}
+}
+
+func TestProcessor_Subscribe(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+	cfg.HistoryCount = 10
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	newest := proc.Subscribe("newest", 2, DropNewest)
+	oldest := proc.Subscribe("oldest", 2, DropOldest)
+
+	press(proc, input.KEY_A, input.KEY_B, input.KEY_C)
+
+	if got := (<-newest.Events()).Text; got != "A" {
+		t.Errorf("First DropNewest event = %q, want A", got)
+	}
+	if n := newest.Dropped(); n != 1 {
+		t.Errorf("DropNewest dropped %d events, want 1", n)
+	}
+
+	if got := (<-oldest.Events()).Text; got != "B" {
+		t.Errorf("First DropOldest event = %q, want B", got)
+	}
+	last := <-oldest.Events()
+	if last.Text != "C" || len(last.History) != 3 || last.History[2].Text != "C" {
+		t.Errorf("Last event = %q with history %d entries, want C with 3", last.Text, len(last.History))
+	}
+	if n := oldest.Dropped(); n != 1 {
+		t.Errorf("DropOldest dropped %d events, want 1", n)
+	}
+
+	oldest.Close()
+	if _, ok := <-oldest.Events(); ok {
+		t.Error("Events channel should be closed after Close")
+	}
+	press(proc, input.KEY_D)
+
+	// Closing twice at once must not close the channels twice.
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(newest.Close)
+	}
+	wg.Wait()
+}
+
+func TestProcessor_SubscribeBlock(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShowHeldKeys = false
+
+	proc := New(cfg)
+	defer proc.Stop()
+
+	sub := proc.Subscribe("block", 1, Block)
+	done := make(chan struct{})
+	go func() {
+		press(proc, input.KEY_A, input.KEY_B)
+		close(done)
+	}()
+
+	for _, want := range []string{"A", "B"} {
+		if got := (<-sub.Events()).Text; got != want {
+			t.Errorf("Event = %q, want %q", got, want)
+		}
+	}
+	<-done
+	if n := sub.Dropped(); n != 0 {
+		t.Errorf("Block dropped %d events, want 0", n)
+	}
+}
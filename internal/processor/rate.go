@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"time"
+
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+// rateTickInterval is how often the rate readout is refreshed so it decays
+// while no keys are pressed.
+const rateTickInterval = time.Second
+
+// rateCounter measures typing speed over a rolling window.
+type rateCounter struct {
+	keys     []time.Time
+	chars    []time.Time // printable keys, for words per minute
+	pausedAt time.Time
+	timer    *time.Timer
+}
+
+func (r *rateCounter) prune(now time.Time, window time.Duration) {
+	cutoff := now.Add(-window)
+	for len(r.keys) > 0 && !r.keys[0].After(cutoff) {
+		r.keys = r.keys[1:]
+	}
+	for len(r.chars) > 0 && !r.chars[0].After(cutoff) {
+		r.chars = r.chars[1:]
+	}
+}
+
+// pause freezes the counter; resume shifts the recorded keys by the paused
+// time so the window continues where it left off.
+func (r *rateCounter) pause(now time.Time) {
+	r.pausedAt = now
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+func (r *rateCounter) resume(now time.Time) {
+	if r.pausedAt.IsZero() {
+		return
+	}
+	shift := now.Sub(r.pausedAt)
+	for i := range r.keys {
+		r.keys[i] = r.keys[i].Add(shift)
+	}
+	for i := range r.chars {
+		r.chars[i] = r.chars[i].Add(shift)
+	}
+	r.pausedAt = time.Time{}
+}
+
+// countKey records a key press for the rate readout.
+func (p *Processor) countKey(code uint16) {
+	if !p.config.ShowRate {
+		return
+	}
+	now := time.Now()
+	p.rate.keys = append(p.rate.keys, now)
+	if input.IsPrintable(code) {
+		p.rate.chars = append(p.rate.chars, now)
+	}
+	p.emitRate()
+}
+
+// emitRate publishes keys and words per minute, counting five printable
+// characters as a word, and keeps refreshing while the window has keys.
+func (p *Processor) emitRate() {
+	window := p.config.RateWindow
+	p.rate.prune(time.Now(), window)
+
+	perMinute := float64(time.Minute) / float64(window)
+	p.publish(DisplayEvent{
+		IsStats:   true,
+		KPM:       int(float64(len(p.rate.keys)) * perMinute),
+		WPM:       int(float64(len(p.rate.chars)) / 5 * perMinute),
+		Timestamp: time.Now(),
+	})
+
+	if p.rate.timer != nil {
+		p.rate.timer.Stop()
+		p.rate.timer = nil
+	}
+	if len(p.rate.keys) == 0 {
+		return
+	}
+	var t *time.Timer
+	t = time.AfterFunc(rateTickInterval, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.rate.timer == t {
+			p.rate.timer = nil
+			p.emitRate()
+		}
+	})
+	p.rate.timer = t
+}
@@ -0,0 +1,45 @@
+package processor
+
+import "time"
+
+// recordScrollback keeps a copy of a history entry in the scrollback ring,
+// which outlives the history so entries can be recalled after they expire.
+func (p *Processor) recordScrollback(event DisplayEvent) {
+	if p.config.ScrollbackCount <= 0 {
+		return
+	}
+	for i := range p.scrollback {
+		if p.scrollback[i].ID == event.ID {
+			p.scrollback[i] = event
+			return
+		}
+	}
+	if len(p.scrollback) >= p.config.ScrollbackCount {
+		p.scrollback = p.scrollback[1:]
+	}
+	p.scrollback = append(p.scrollback, event)
+}
+
+// recall shows the most recent scrollback entries for RecallDuration. An
+// event with no Recall entries tells the display to hide them again.
+func (p *Processor) recall() {
+	n := min(p.config.RecallCount, len(p.scrollback))
+	entries := make([]DisplayEvent, n)
+	copy(entries, p.scrollback[len(p.scrollback)-n:])
+
+	if p.recallTimer != nil {
+		p.recallTimer.Stop()
+	}
+	var t *time.Timer
+	t = time.AfterFunc(p.config.RecallDuration, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.recallTimer == t {
+			p.recallTimer = nil
+			p.publish(DisplayEvent{IsRecall: true, Timestamp: time.Now()})
+		}
+	})
+	p.recallTimer = t
+
+	p.publish(DisplayEvent{IsRecall: true, Recall: entries, Timestamp: time.Now()})
+}
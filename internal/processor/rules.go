@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+// Rule actions.
+const (
+	RuleHide     = "hide"
+	RuleRelabel  = "relabel"  // show Label instead of the key
+	RuleAnnotate = "annotate" // show Label after the key
+	RuleMask     = "mask"
+	RuleExtend   = "extend" // keep the entry for Lifetime
+)
+
+// Rule transforms matching key presses. Every criterion that is set must
+// match: the combo (e.g. "Ctrl+S"), the key class, the focused app, and
+// the number of times in a row the combo was pressed.
+type Rule struct {
+	Combo    string
+	Class    string // a key class name or "unmodified_printable"
+	Apps     []string
+	Repeat   int
+	Action   string
+	Label    string
+	Lifetime time.Duration
+}
+
+// ruleResult is what the matching rules did to a key press.
+type ruleResult struct {
+	text     string
+	masked   bool
+	hidden   bool
+	lifetime time.Duration
+}
+
+func normalizeRules(rules []Rule) []Rule {
+	result := make([]Rule, len(rules))
+	for i, rule := range rules {
+		if rule.Combo != "" {
+			rule.Combo = normalizeKeyCombo(rule.Combo)
+		}
+		result[i] = rule
+	}
+	return result
+}
+
+// trackRepeat counts consecutive presses of the same combo for rules.
+func (p *Processor) trackRepeat(combo string) {
+	if combo == p.repeatCombo {
+		p.repeatCount++
+		return
+	}
+	p.repeatCombo = combo
+	p.repeatCount = 1
+}
+
+// applyRules runs every matching rule in order on a key press about to be
+// shown as text. A hide action stops evaluation.
+func (p *Processor) applyRules(ev input.KeyEvent, text string, masked bool) ruleResult {
+	res := ruleResult{text: text, masked: masked}
+	if len(p.config.Rules) == 0 {
+		return res
+	}
+
+	combo := p.pressedCombo(ev.Name)
+	p.trackRepeat(combo)
+
+	for _, rule := range p.config.Rules {
+		if !p.ruleMatches(rule, ev.Code, combo) {
+			continue
+		}
+		switch rule.Action {
+		case RuleHide:
+			res.hidden = true
+			return res
+		case RuleRelabel:
+			res.text = rule.Label
+		case RuleAnnotate:
+			res.text += " " + rule.Label
+		case RuleMask:
+			res.text = MaskGlyph
+			res.masked = true
+		case RuleExtend:
+			res.lifetime = rule.Lifetime
+		}
+	}
+	return res
+}
+
+func (p *Processor) ruleMatches(rule Rule, code uint16, combo string) bool {
+	if rule.Combo != "" && rule.Combo != combo {
+		return false
+	}
+	if rule.Class != "" && !p.matchesClass(rule.Class, code) {
+		return false
+	}
+	if rule.Repeat > 0 && p.repeatCount < rule.Repeat {
+		return false
+	}
+	return p.appMatches(rule.Apps)
+}
+
+func (p *Processor) matchesClass(name string, code uint16) bool {
+	if strings.EqualFold(strings.TrimSpace(name), UnmodifiedPrintable) {
+		return p.modifiers&shortcutMods == 0 && input.IsPrintable(code)
+	}
+	class, ok := input.ParseKeyClass(name)
+	return ok && input.GetKeyClass(code) == class
+}
@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"math"
+	"slices"
+	"time"
+	"unicode"
+
+	"github.com/tapshow/tapshow/internal/i18n"
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+// SecretRules decides when a run of typed characters ended by Enter looks
+// like a password.
+type SecretRules struct {
+	MinLength      int
+	MinEntropyBits float64 // Shannon entropy of the whole run
+	MinClasses     int     // of lowercase, uppercase, digits and symbols
+}
+
+// secretRun collects unmodified printable keys typed since the last
+// non-printable key, along with the history entries showing them.
+type secretRun struct {
+	chars []rune
+	ids   []uint64
+}
+
+func (r *secretRun) reset() {
+	r.chars = r.chars[:0]
+	r.ids = r.ids[:0]
+}
+
+// trackSecret extends the current run with a key press and, when Enter ends
+// a run that looks like a secret, retracts it from the history.
+func (p *Processor) trackSecret(ev input.KeyEvent, entryID uint64) {
+	if !p.config.RetractSecrets {
+		return
+	}
+	run := &p.secret
+
+	if p.modifiers&shortcutMods != 0 {
+		run.reset()
+		return
+	}
+
+	switch ev.Code {
+	case input.KEY_ENTER, input.KEY_KPENTER:
+		// A chord still waiting to be shown may hold the end of the run.
+		if p.chord != nil && p.chord.secret {
+			p.flushChord()
+		}
+		if looksLikeSecret(run.chars, p.config.SecretRules) {
+			p.retract(run.ids)
+		}
+		run.reset()
+		return
+	case input.KEY_BACKSPACE:
+		if len(run.chars) > 0 {
+			run.chars = run.chars[:len(run.chars)-1]
+		}
+		p.trackSecretEntry(entryID)
+		return
+	}
+
+	r, ok := input.KeyChar(ev.Code, p.modifiers&input.ModShift != 0)
+	if !ok {
+		run.reset()
+		return
+	}
+	run.chars = append(run.chars, r)
+	p.trackSecretEntry(entryID)
+}
+
+// trackSecretEntry adds the entry showing a key to the run. Keys waiting in
+// a chord have no entry yet, so the chord adds it once it is flushed.
+func (p *Processor) trackSecretEntry(entryID uint64) {
+	switch {
+	case entryID != 0:
+		p.secret.ids = append(p.secret.ids, entryID)
+	case p.chord != nil:
+		p.chord.secret = true
+	}
+}
+
+// retract replaces the given entries in the history and scrollback with a
+// single "[redacted]" marker.
+func (p *Processor) retract(ids []uint64) {
+	if len(ids) == 0 {
+		return
+	}
+
+	p.nextID++
+	marker := DisplayEvent{ID: p.nextID, Text: i18n.T("redacted"), Timestamp: time.Now(), lifetime: p.lifetime(kindSpecial)}
+	marker.touch(marker.Timestamp)
+
+	p.history = replaceEntries(p.history, ids, marker)
+	p.scrollback = replaceEntries(p.scrollback, ids, marker)
+	p.recent = p.recent[:0]
+
+	p.scheduleExpiry()
+	p.send(marker)
+}
+
+// replaceEntries removes the entries with the given IDs, putting marker in
+// place of the first one.
+func replaceEntries(entries []DisplayEvent, ids []uint64, marker DisplayEvent) []DisplayEvent {
+	result := entries[:0]
+	placed := false
+	for _, e := range entries {
+		if !slices.Contains(ids, e.ID) {
+			result = append(result, e)
+			continue
+		}
+		if !placed {
+			result = append(result, marker)
+			placed = true
+		}
+	}
+	return result
+}
+
+func looksLikeSecret(chars []rune, rules SecretRules) bool {
+	if len(chars) == 0 || len(chars) < rules.MinLength || slices.Contains(chars, ' ') {
+		return false
+	}
+	return charClasses(chars) >= rules.MinClasses && entropyBits(chars) >= rules.MinEntropyBits
+}
+
+func charClasses(chars []rune) int {
+	var lower, upper, digit, symbol int
+	for _, r := range chars {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// entropyBits returns the Shannon entropy of chars times their count.
+func entropyBits(chars []rune) float64 {
+	counts := make(map[rune]int)
+	for _, r := range chars {
+		counts[r]++
+	}
+	n := float64(len(chars))
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h * n
+}
@@ -0,0 +1,173 @@
+package processor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tapshow/tapshow/internal/compose"
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+type sequenceKind int
+
+const (
+	seqCompose sequenceKind = iota + 1
+	seqDead
+	seqUnicode
+)
+
+// maxUnicodeDigits is the longest hex code point accepted by Ctrl+Shift+U.
+const maxUnicodeDigits = 8
+
+// sequence collects the keys of a compose, dead key or Unicode hex entry
+// sequence so they can be shown as one entry with the resulting character.
+type sequence struct {
+	kind     sequenceKind
+	parts    []string // shown keys, e.g. "Compose", "'", "e"
+	syms     []string // keysyms looked up in the compose table
+	hex      []rune
+	heldMods bool // hex digits were typed while Ctrl+Shift were held
+}
+
+// handleSequenceKey starts or continues a character entry sequence. It
+// reports whether the key was consumed by the sequence.
+func (p *Processor) handleSequenceKey(ev input.KeyEvent) bool {
+	if p.sequence == nil {
+		return p.startSequence(ev)
+	}
+	if p.sequence.kind == seqUnicode {
+		return p.continueUnicode(ev)
+	}
+	return p.continueCompose(ev)
+}
+
+func (p *Processor) startSequence(ev input.KeyEvent) bool {
+	if ev.Code == input.KEY_U && p.modifiers&shortcutMods == input.ModCtrl && p.modifiers&input.ModShift != 0 {
+		text, _ := p.formatKeys(input.ModCtrl|input.ModShift, ev.Name)
+		p.sequence = &sequence{kind: seqUnicode, parts: []string{text}}
+		return true
+	}
+
+	if p.modifiers&shortcutMods != 0 {
+		return false
+	}
+
+	if ev.Code == input.KEY_COMPOSE {
+		text, _ := p.formatKeys(0, ev.Name)
+		p.sequence = &sequence{kind: seqCompose, parts: []string{text}, syms: []string{"Multi_key"}}
+		return true
+	}
+
+	r, ok := input.KeyChar(ev.Code, p.modifiers&input.ModShift != 0)
+	if !ok || !p.deadKeys[r] {
+		return false
+	}
+	sym, ok := compose.DeadKeysym(r)
+	if !ok {
+		return false
+	}
+	p.sequence = &sequence{kind: seqDead, parts: []string{string(r)}, syms: []string{sym}}
+	return true
+}
+
+func (p *Processor) continueCompose(ev input.KeyEvent) bool {
+	s := p.sequence
+
+	r, ok := input.KeyChar(ev.Code, p.modifiers&input.ModShift != 0)
+	if !ok || p.modifiers&shortcutMods != 0 {
+		p.finishSequence("")
+		return false
+	}
+
+	sym := compose.KeysymName(r)
+	if dead, isDead := compose.DeadKeysym(r); isDead && s.kind == seqDead && p.deadKeys[r] {
+		sym = dead
+	}
+	s.syms = append(s.syms, sym)
+	s.parts = append(s.parts, p.charLabel(r))
+
+	if p.config.ComposeTable == nil {
+		p.finishSequence("")
+		return true
+	}
+
+	result, complete, isPrefix := p.config.ComposeTable.Lookup(s.syms)
+	switch {
+	case complete:
+		p.finishSequence(result)
+	case !isPrefix:
+		p.finishSequence("")
+	}
+	return true
+}
+
+func (p *Processor) continueUnicode(ev input.KeyEvent) bool {
+	s := p.sequence
+
+	switch ev.Code {
+	case input.KEY_SPACE, input.KEY_ENTER, input.KEY_KPENTER:
+		p.commitUnicode()
+		return true
+	case input.KEY_BACKSPACE:
+		if len(s.hex) > 0 {
+			s.hex = s.hex[:len(s.hex)-1]
+		}
+		return true
+	}
+
+	r, ok := input.KeyChar(ev.Code, false)
+	if !ok || !strings.ContainsRune("0123456789abcdef", r) || len(s.hex) >= maxUnicodeDigits {
+		p.finishSequence("")
+		return false
+	}
+
+	s.hex = append(s.hex, r)
+	s.heldMods = p.modifiers&(input.ModCtrl|input.ModShift) == input.ModCtrl|input.ModShift
+	return true
+}
+
+// sequenceModifierReleased commits Unicode hex entry when Ctrl+Shift are
+// released after typing the digits with them held, as GTK does.
+func (p *Processor) sequenceModifierReleased() {
+	s := p.sequence
+	if s == nil || s.kind != seqUnicode || !s.heldMods || len(s.hex) == 0 {
+		return
+	}
+	if p.modifiers&(input.ModCtrl|input.ModShift) == 0 {
+		p.commitUnicode()
+	}
+}
+
+func (p *Processor) commitUnicode() {
+	s := p.sequence
+	result := ""
+	if code, err := strconv.ParseUint(string(s.hex), 16, 32); err == nil && code > 0 {
+		result = string(rune(code))
+	}
+	p.finishSequence(result)
+}
+
+// finishSequence shows the collected sequence, followed by its result when
+// it produced a character.
+func (p *Processor) finishSequence(result string) {
+	s := p.sequence
+	p.sequence = nil
+
+	parts := s.parts
+	if len(s.hex) > 0 {
+		parts = append(parts, string(s.hex))
+	}
+	text := strings.Join(parts, " ")
+	if result != "" {
+		text += " → " + result
+	}
+
+	p.emitEvent(text, p.lifetime(kindPrintable))
+}
+
+func (p *Processor) charLabel(r rune) string {
+	if r == ' ' {
+		return p.label("Space")
+	}
+	return string(r)
+}
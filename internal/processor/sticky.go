@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"time"
+
+	"github.com/tapshow/tapshow/internal/input"
+)
+
+// stickyMods holds modifiers that were tapped on their own and apply to the
+// next key, like one-shot modifiers or the StickyKeys accessibility feature.
+type stickyMods struct {
+	mods  input.Modifier
+	timer *time.Timer
+}
+
+// trackModifierTap arms a modifier that was released without any other key
+// being pressed while it was down. Tapping an armed modifier disarms it.
+func (p *Processor) trackModifierTap(mod input.Modifier, state input.KeyState) {
+	switch state {
+	case input.KeyPressed:
+		p.tapped |= mod
+	case input.KeyReleased:
+		if p.tapped&mod == 0 {
+			return
+		}
+		p.tapped &^= mod
+		if p.sticky != nil && p.sticky.mods&mod != 0 {
+			p.sticky.mods &^= mod
+			if p.sticky.mods == 0 {
+				p.disarm()
+				return
+			}
+		} else {
+			p.arm(mod)
+		}
+		p.emitArmed()
+	}
+}
+
+func (p *Processor) arm(mod input.Modifier) {
+	if p.sticky != nil {
+		p.sticky.mods |= mod
+		p.sticky.timer.Reset(p.config.StickyTimeout)
+		return
+	}
+
+	s := &stickyMods{mods: mod}
+	s.timer = time.AfterFunc(p.config.StickyTimeout, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.sticky == s {
+			p.disarm()
+		}
+	})
+	p.sticky = s
+}
+
+// takeSticky disarms the sticky modifiers and returns them so that they can
+// be combined with the key being pressed.
+func (p *Processor) takeSticky() input.Modifier {
+	if p.sticky == nil {
+		return 0
+	}
+	mods := p.sticky.mods
+	p.disarm()
+	return mods
+}
+
+func (p *Processor) disarm() {
+	if p.sticky == nil {
+		return
+	}
+	p.sticky.timer.Stop()
+	p.sticky = nil
+	p.emitArmed()
+}
+
+// emitArmed shows the armed modifiers waiting for the next key. An empty
+// text tells the display that nothing is armed anymore.
+func (p *Processor) emitArmed() {
+	text := ""
+	if p.sticky != nil {
+		text, _ = p.formatKeys(p.sticky.mods, "…")
+	}
+	p.publish(DisplayEvent{
+		Text:      text,
+		Timestamp: time.Now(),
+		IsArmed:   true,
+	})
+}
@@ -0,0 +1,105 @@
+package processor
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what happens when a subscriber's buffer is full.
+type OverflowPolicy int
+
+const (
+	DropNewest OverflowPolicy = iota // discard the event being sent
+	DropOldest                       // discard the oldest buffered event
+	Block                            // wait until the subscriber catches up
+)
+
+// Subscription is one consumer's stream of events.
+type Subscription struct {
+	name    string
+	events  chan DisplayEvent
+	policy  OverflowPolicy
+	dropped atomic.Uint64
+	closed  chan struct{}
+	once    sync.Once
+	proc    *Processor
+}
+
+// Subscribe returns a new stream of every event the processor sends from
+// now on. A Block subscriber stalls the processor while its buffer is full,
+// so it must not wait on the processor itself while reading.
+func (p *Processor) Subscribe(name string, buffer int, policy OverflowPolicy) *Subscription {
+	s := &Subscription{
+		name:   name,
+		events: make(chan DisplayEvent, buffer),
+		policy: policy,
+		closed: make(chan struct{}),
+		proc:   p,
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subs = append(p.subs, s)
+	return s
+}
+
+func (s *Subscription) Name() string {
+	return s.name
+}
+
+func (s *Subscription) Events() <-chan DisplayEvent {
+	return s.events
+}
+
+// Dropped returns how many events were discarded because the buffer was
+// full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops delivery and closes the Events channel.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		close(s.closed)
+
+		p := s.proc
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i, sub := range p.subs {
+			if sub == s {
+				p.subs = append(p.subs[:i], p.subs[i+1:]...)
+				break
+			}
+		}
+		close(s.events)
+	})
+}
+
+func (s *Subscription) deliver(event DisplayEvent, done <-chan struct{}) {
+	switch s.policy {
+	case Block:
+		select {
+		case s.events <- event:
+		case <-s.closed:
+		case <-done:
+		}
+	case DropOldest:
+		for {
+			select {
+			case s.events <- event:
+				return
+			default:
+			}
+			select {
+			case <-s.events:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.events <- event:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
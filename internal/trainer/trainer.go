@@ -0,0 +1,133 @@
+package trainer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/tapshow/tapshow/internal/i18n"
+)
+
+// Card asks for the combo that performs an action, e.g. "Open command
+// palette" and "Ctrl+Shift+P".
+type Card struct {
+	Description string `toml:"description"`
+	Combo       string `toml:"combo"`
+}
+
+type Deck struct {
+	Name  string `toml:"name"`
+	Cards []Card `toml:"cards"`
+}
+
+func LoadDeck(path string) (*Deck, error) {
+	var deck Deck
+	if _, err := toml.DecodeFile(path, &deck); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T("err_parsing_deck"), err)
+	}
+	if len(deck.Cards) == 0 {
+		return nil, fmt.Errorf("%s: %s", i18n.T("err_deck_empty"), path)
+	}
+	for i, card := range deck.Cards {
+		if card.Description == "" || card.Combo == "" {
+			return nil, fmt.Errorf("%s: %s #%d", i18n.T("err_deck_card"), path, i+1)
+		}
+	}
+	return &deck, nil
+}
+
+type Result struct {
+	Card    Card
+	Answer  string
+	Correct bool
+}
+
+// Session walks through a deck one card at a time.
+type Session struct {
+	deck    *Deck
+	results []Result
+}
+
+func NewSession(deck *Deck) *Session {
+	return &Session{deck: deck}
+}
+
+// Current returns the card waiting for an answer, or false once every card
+// has been answered.
+func (s *Session) Current() (Card, bool) {
+	if s.Done() {
+		return Card{}, false
+	}
+	return s.deck.Cards[len(s.results)], true
+}
+
+// Position returns the 1-based number of the current card and the deck size.
+func (s *Session) Position() (int, int) {
+	return len(s.results) + 1, len(s.deck.Cards)
+}
+
+// Answer checks a pressed combo against the current card and moves on to
+// the next one.
+func (s *Session) Answer(combo string) Result {
+	card, ok := s.Current()
+	if !ok {
+		return Result{}
+	}
+	result := Result{Card: card, Answer: combo, Correct: SameCombo(combo, card.Combo)}
+	s.results = append(s.results, result)
+	return result
+}
+
+func (s *Session) Done() bool {
+	return len(s.results) >= len(s.deck.Cards)
+}
+
+func (s *Session) Results() []Result {
+	return s.results
+}
+
+func (s *Session) Score() (correct, total int) {
+	for _, r := range s.results {
+		if r.Correct {
+			correct++
+		}
+	}
+	return correct, len(s.deck.Cards)
+}
+
+var keyAliases = map[string]string{
+	"control": "ctrl",
+	"meta":    "super",
+	"win":     "super",
+	"cmd":     "super",
+	"option":  "alt",
+	"return":  "enter",
+	"escape":  "esc",
+	"del":     "delete",
+	"pgup":    "pageup",
+	"pgdn":    "pagedown",
+}
+
+// SameCombo reports whether two combos name the same keys, ignoring case,
+// modifier order and common aliases like "Control" or "Return".
+func SameCombo(a, b string) bool {
+	return normalize(a) == normalize(b)
+}
+
+func normalize(combo string) string {
+	var parts []string
+	for _, part := range strings.Split(combo, "+") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		if alias, ok := keyAliases[part]; ok {
+			part = alias
+		}
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "+")
+}
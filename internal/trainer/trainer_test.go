@@ -0,0 +1,75 @@
+package trainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDeck(t *testing.T) {
+	content := `
+name = "VS Code basics"
+
+[[cards]]
+description = "Open command palette"
+combo = "Ctrl+Shift+P"
+
+[[cards]]
+description = "Quick open"
+combo = "Ctrl+P"
+`
+	path := filepath.Join(t.TempDir(), "deck.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	deck, err := LoadDeck(path)
+	if err != nil {
+		t.Fatalf("LoadDeck failed: %v", err)
+	}
+	if deck.Name != "VS Code basics" || len(deck.Cards) != 2 {
+		t.Fatalf("Deck = %+v, want 2 cards named VS Code basics", deck)
+	}
+	if deck.Cards[0].Combo != "Ctrl+Shift+P" {
+		t.Errorf("First combo = %q, want Ctrl+Shift+P", deck.Cards[0].Combo)
+	}
+}
+
+func TestLoadDeckInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deck.toml")
+	if err := os.WriteFile(path, []byte("[[cards]]\ndescription = \"No combo\"\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if _, err := LoadDeck(path); err == nil {
+		t.Error("LoadDeck should fail for a card without a combo")
+	}
+}
+
+func TestSession(t *testing.T) {
+	deck := &Deck{Cards: []Card{
+		{Description: "Open command palette", Combo: "Ctrl+Shift+P"},
+		{Description: "Close tab", Combo: "Control+W"},
+	}}
+	s := NewSession(deck)
+
+	if card, ok := s.Current(); !ok || card.Description != "Open command palette" {
+		t.Fatalf("Current = %+v, %v", card, ok)
+	}
+	if r := s.Answer("Shift+Ctrl+P"); !r.Correct {
+		t.Error("Shift+Ctrl+P should match Ctrl+Shift+P")
+	}
+	if n, total := s.Position(); n != 2 || total != 2 {
+		t.Errorf("Position = %d/%d, want 2/2", n, total)
+	}
+	if r := s.Answer("Ctrl+Q"); r.Correct {
+		t.Error("Ctrl+Q should not match Control+W")
+	}
+
+	if !s.Done() {
+		t.Error("Session should be done after answering every card")
+	}
+	if correct, total := s.Score(); correct != 1 || total != 2 {
+		t.Errorf("Score = %d/%d, want 1/2", correct, total)
+	}
+}